@@ -2,17 +2,43 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 )
 
+// DefaultTrailer is the key of the trailer that credits a collaborator unless another one is chosen
+const DefaultTrailer = "Co-authored-by"
+
+var creditPattern = regexp.MustCompile(`^([A-Za-z0-9-]+):\s*(.*?)\s*<([^>]*)>\s*$`)
+
+var trailerKeyPattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
 // Collaborator represents a pairing partner
 type Collaborator struct {
 	Alias string `json:"-"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	// Trailer is the key of the trailer that credits the collaborator in a pairing, or empty for DefaultTrailer.
+	// It belongs to the pairing rather than the collaborator, so sessions save it themselves.
+	Trailer string `json:"-"`
 }
 
 func (c Collaborator) String() string {
-	return fmt.Sprintf("Co-authored-by: %s <%s>", c.Name, c.Email)
+	return fmt.Sprintf("%s: %s <%s>", c.GetTrailer(), c.Name, c.Email)
+}
+
+// GetTrailer returns the key of the trailer that credits the collaborator
+func (c Collaborator) GetTrailer() string {
+	if c.Trailer == "" {
+		return DefaultTrailer
+	}
+
+	return c.Trailer
+}
+
+// IsValidTrailer returns true if key can be the key of a git trailer, such as "Reviewed-by"
+func IsValidTrailer(key string) bool {
+	return trailerKeyPattern.MatchString(key)
 }
 
 // NewCollaborator returns a new collaborator with the given properties
@@ -20,6 +46,84 @@ func NewCollaborator(alias, name, email string) Collaborator {
 	return Collaborator{Alias: alias, Name: name, Email: email}
 }
 
+// ParseCredit parses a trailer of any key crediting someone, such as "Reviewed-by: Name <email>",
+// into a Collaborator without an alias. The trailer is left empty if it is DefaultTrailer.
+func ParseCredit(trailer string) (Collaborator, bool) {
+	match := creditPattern.FindStringSubmatch(strings.TrimSpace(trailer))
+	if match == nil {
+		return Collaborator{}, false
+	}
+
+	collaborator := Collaborator{Name: match[2], Email: match[3], Trailer: match[1]}
+	if strings.EqualFold(collaborator.Trailer, DefaultTrailer) {
+		collaborator.Trailer = ""
+	}
+
+	return collaborator, true
+}
+
+// FindByEmail returns the collaborator with the given email, ignoring case
+func FindByEmail(collaborators []Collaborator, email string) (Collaborator, bool) {
+	for _, collaborator := range collaborators {
+		if strings.EqualFold(collaborator.Email, email) {
+			return collaborator, true
+		}
+	}
+
+	return Collaborator{}, false
+}
+
+// ClosestAlias returns the alias of the collaborator whose alias is most similar to the given one,
+// for suggesting a fix to a typo, or false if none is close enough
+func ClosestAlias(collaborators []Collaborator, alias string) (string, bool) {
+	closest := ""
+	best := len(alias)/3 + 1
+	for _, collaborator := range collaborators {
+		distance := editDistance(strings.ToLower(alias), strings.ToLower(collaborator.Alias))
+		if distance <= best && (closest == "" || distance < best || collaborator.Alias < closest) {
+			closest, best = collaborator.Alias, distance
+		}
+	}
+
+	return closest, closest != ""
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+
+	return result
+}
+
 // Less returns true if a should be sorted before b, false otherwise
 func Less(a, b Collaborator) bool {
 	if a.Alias < b.Alias {
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCredit(t *testing.T) {
+	tests := []struct {
+		name    string
+		trailer string
+		want    Collaborator
+		wantOk  bool
+	}{
+		{"co-author", "Co-authored-by: name1 <email1>", Collaborator{Name: "name1", Email: "email1"}, true},
+		{"lowercase co-author", "co-authored-by: name1 <email1>", Collaborator{Name: "name1", Email: "email1"}, true},
+		{"other trailer", "Reviewed-by: name1 <email1>", Collaborator{Name: "name1", Email: "email1", Trailer: "Reviewed-by"}, true},
+		{"no email", "Ticket: ABC-123", Collaborator{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseCredit(tt.trailer)
+			if ok != tt.wantOk {
+				t.Errorf("ParseCredit() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCredit() = %v, want %v", got, tt.want)
+			}
+			if ok && got.String() != tt.trailer && !strings.EqualFold(got.String(), tt.trailer) {
+				t.Errorf("ParseCredit().String() = %q, want %q", got.String(), tt.trailer)
+			}
+		})
+	}
+}
+
+func TestClosestAlias(t *testing.T) {
+	roster := []Collaborator{
+		NewCollaborator("alice", "Alice", "alice@example.com"),
+		NewCollaborator("bob", "Bob", "bob@example.com"),
+		NewCollaborator("carol", "Carol", "carol@example.com"),
+	}
+
+	tests := []struct {
+		alias  string
+		want   string
+		wantOk bool
+	}{
+		{"alcie", "alice", true},
+		{"Bob", "bob", true},
+		{"bo", "bob", true},
+		{"carl", "carol", true},
+		{"zed", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			got, ok := ClosestAlias(roster, tt.alias)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ClosestAlias() = %s, %v, want %s, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
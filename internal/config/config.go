@@ -1,5 +1,12 @@
 package config
 
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
 // Config is the persisted config for gpair, including a dictionary of collaborators
 type Config struct {
 	Collaborators map[string]Collaborator `json:"collaborators"`
@@ -11,3 +18,26 @@ func NewConfig() Config {
 		Collaborators: make(map[string]Collaborator),
 	}
 }
+
+// ReadCollaborators returns the collaborators in a file in the format of ~/.gpair/config.json, such as a roster
+// committed to a repo, without ever writing to it
+func ReadCollaborators(path string) ([]Collaborator, error) {
+	jsonBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	err = json.Unmarshal(jsonBytes, &config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse %s", path)
+	}
+
+	var collaborators []Collaborator
+	for alias, collab := range config.Collaborators {
+		collab.Alias = alias
+		collaborators = append(collaborators, collab)
+	}
+
+	return collaborators, nil
+}
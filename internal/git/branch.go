@@ -0,0 +1,35 @@
+package git
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// GetCurrentBranch returns the short name of the checked out branch, or "" if HEAD is detached
+func GetCurrentBranch() (string, error) {
+	cmd := exec.Command("git", "symbolic-ref", "--short", "-q", "HEAD")
+	branchBytes, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if exitErr.ExitCode() == 1 {
+				// git symbolic-ref -q exits with code 1 if HEAD is detached
+				return "", nil
+			}
+		}
+
+		return "", err
+	}
+
+	return strings.TrimSpace(string(branchBytes)), nil
+}
+
+// GetBranches returns the short names of all local branches in the current repo
+func GetBranches() ([]string, error) {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname:short)", "refs/heads")
+	branchesBytes, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(string(branchesBytes)), nil
+}
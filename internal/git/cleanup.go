@@ -0,0 +1,92 @@
+package git
+
+import (
+	"strings"
+)
+
+// Modes of commit.cleanup, which decide how git cleans up a commit message before committing it
+const (
+	CleanupDefault    = "default"
+	CleanupStrip      = "strip"
+	CleanupWhitespace = "whitespace"
+	CleanupVerbatim   = "verbatim"
+	CleanupScissors   = "scissors"
+)
+
+// defaultCommentChar is the comment character git uses unless core.commentChar is set
+const defaultCommentChar = "#"
+
+// autoCommentChars are the characters git picks from, in order, when core.commentChar is "auto"
+const autoCommentChars = "#;@!$%^&|:"
+
+// GetCleanupMode returns the effective commit.cleanup, or CleanupDefault if it is not set
+func GetCleanupMode() (string, error) {
+	cleanup, err := getConfig("commit.cleanup")
+	if err != nil || cleanup == "" {
+		return CleanupDefault, err
+	}
+
+	return strings.ToLower(cleanup), nil
+}
+
+// StripsComments returns true if git removes comment lines from a commit message edited from a template
+// in the given cleanup mode. This is the default when the message is edited.
+func StripsComments(cleanup string) bool {
+	return cleanup == CleanupDefault || cleanup == CleanupStrip
+}
+
+// GetCommentChar returns the effective core.commentString or core.commentChar, which defaults to "#".
+// If it is "auto", the character git would pick for message is returned.
+func GetCommentChar(message string) (string, error) {
+	commentChar, err := getCommentSetting()
+	if err != nil {
+		return "", err
+	}
+
+	if commentChar == "auto" {
+		return autoCommentChar(message), nil
+	}
+
+	return commentChar, nil
+}
+
+// getCommentSetting returns the effective core.commentString or core.commentChar, which may be "auto"
+func getCommentSetting() (string, error) {
+	commentChar, err := getConfig("core.commentString")
+	if err != nil || commentChar != "" {
+		return commentChar, err
+	}
+
+	commentChar, err = getConfig("core.commentChar")
+	if err != nil || commentChar != "" {
+		return commentChar, err
+	}
+
+	return defaultCommentChar, nil
+}
+
+// autoCommentChar returns the comment character git picks for message when core.commentChar is "auto".
+// If git has already added its comments to message, the character they start with is returned.
+func autoCommentChar(message string) string {
+	lines := strings.Split(strings.TrimRight(message, "\n"), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last != "" && strings.ContainsAny(last[:1], autoCommentChars) && (len(last) == 1 || last[1] == ' ') {
+		return last[:1]
+	}
+
+	// Otherwise git picks the first character that does not start a line of the message
+	used := make(map[byte]bool)
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			used[line[0]] = true
+		}
+	}
+
+	for i := 0; i < len(autoCommentChars); i++ {
+		if !used[autoCommentChars[i]] {
+			return autoCommentChars[i : i+1]
+		}
+	}
+
+	return defaultCommentChar
+}
@@ -1,9 +1,30 @@
 package git
 
 import (
+	"crypto/sha256"
+	"fmt"
+	"encoding/hex"
 	"strings"
 	"path/filepath"
 	"os/exec"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+// Sources that gpair.repoKey can choose to identify a repo by
+const (
+	// PathRepoKey identifies a repo by the absolute path of its top level, so that every checkout has its own pairing
+	PathRepoKey = "path"
+	// RemoteRepoKey identifies a repo by the URL of its origin remote, so that clones of the same repo share a pairing
+	RemoteRepoKey = "remote"
+)
+
+// Backends that gpair can use to add co-author trailers to commits
+const (
+	// TemplateBackend sets git's commit.template to a file containing the trailers
+	TemplateBackend = "template"
+	// HookBackend appends the trailers to every commit message from a git hook
+	HookBackend = "hook"
 )
 
 // IsInstalled returns true if git is on the user's path
@@ -14,46 +35,337 @@ func IsInstalled() bool {
 
 // GetRepoName returns the name of the git repo where gpair was executed
 func GetRepoName() (string, error) {
+	repoRoot, err := GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+
+	_, repoName := filepath.Split(repoRoot)
+
+	return repoName, nil
+}
+
+// GetRepoKey returns a stable identity for the git repo where gpair was executed, under which its state is saved.
+// It is the name of the repo followed by a hash of its top level path, or of its origin URL if gpair.repoKey is "remote",
+// so that two checkouts with the same name do not share a pairing.
+func GetRepoKey() (string, error) {
+	repoRoot, err := GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+
+	source := repoRoot
+
+	repoKey, err := getConfig("gpair.repoKey")
+	if err != nil {
+		return "", err
+	}
+
+	if repoKey == RemoteRepoKey {
+		originURL, err := getConfig("remote.origin.url")
+		if err != nil {
+			return "", err
+		}
+
+		// Linked worktrees share the remote of their clone, but each has a pairing of its own
+		linked, err := IsLinkedWorktree()
+		if err != nil {
+			return "", err
+		}
+
+		if originURL != "" && !linked {
+			source = originURL
+		}
+	}
+
+	return HashRepoKey(repoRoot, source), nil
+}
+
+// HashRepoKey returns the key of the repo at repoRoot, identified by source, which is its path or its origin URL
+func HashRepoKey(repoRoot, source string) string {
+	hash := sha256.Sum256([]byte(source))
+
+	return filepath.Base(repoRoot) + "-" + hex.EncodeToString(hash[:6])
+}
+
+// GetRepoRoot returns the absolute path of the top level of the git repo where gpair was executed
+func GetRepoRoot() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
 	repoPathBytes, err := cmd.Output()
 	if err != nil {
 		return "", err
 	}
 
-	_, repoName := filepath.Split(string(repoPathBytes))
+	return strings.TrimSpace(string(repoPathBytes)), nil
+}
+
+// CheckRepo returns an error if dir is not in a working git repo
+func CheckRepo(dir string) error {
+	cmd := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s", strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// GetSubmodules returns the absolute paths of the submodules of the repo at repoRoot, including nested ones
+func GetSubmodules(repoRoot string) ([]string, error) {
+	cmd := exec.Command("git", "-C", repoRoot, "submodule", "--quiet", "foreach", "--recursive", "pwd")
+	submodulesBytes, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var submodules []string
+	for _, line := range strings.Split(string(submodulesBytes), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			submodules = append(submodules, line)
+		}
+	}
+
+	return submodules, nil
+}
+
+// GetUser returns the user's identity from the effective user.name and user.email
+func GetUser() (config.Collaborator, error) {
+	name, err := getConfig("user.name")
+	if err != nil {
+		return config.Collaborator{}, err
+	}
+
+	email, err := getConfig("user.email")
+	if err != nil {
+		return config.Collaborator{}, err
+	}
+
+	return config.Collaborator{Name: name, Email: email}, nil
+}
+
+// IsGpairPath returns true if the path points at a file managed by gpair
+func IsGpairPath(path string) bool {
+	return strings.Contains(path, ".gpair")
+}
+
+// GetGpairFiles returns the files managed by gpair that commit.template, gpair.pairing or gpair.originalTemplate
+// point at in every git config the current repo reads, or in the config file for a directory when acting on one
+func GetGpairFiles() ([]string, error) {
+	args := []string{"config", "--null"}
+	if strings.HasPrefix(localScope, "--file=") {
+		args = append(args, localScope)
+	}
+	args = append(args, "--get-regexp", `^(commit\.template|gpair\.pairing|gpair\.originaltemplate)$`)
+
+	cmd := exec.Command("git", args...)
+	valuesBytes, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			// git config exits with code 1 if none of the keys are set
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range strings.Split(string(valuesBytes), "\x00") {
+		// Each entry is the key, a newline, then the value
+		parts := strings.SplitN(entry, "\n", 2)
+		if len(parts) == 2 && IsGpairPath(parts[1]) {
+			files = append(files, parts[1])
+		}
+	}
+
+	return files, nil
+}
+
+// SetTemplate sets the current repo's git config commit.template to the provided filepath
+func SetTemplate(templatePath string, global bool) error {
+	return setConfig(global, "commit.template", templatePath)
+}
+
+// UnsetTemplate unsets the current repo's git config commit.template
+func UnsetTemplate(global bool) error {
+	return unsetConfig(global, "commit.template")
+}
+
+// GetTemplate returns the commit.template set in the current repo's git config, or the global one
+func GetTemplate(global bool) (string, error) {
+	return getScopedConfig(global, "commit.template")
+}
+
+// GetEffectiveTemplate returns the commit.template git will use in the current repo
+func GetEffectiveTemplate() (string, error) {
+	return getConfig("commit.template")
+}
+
+// GetOriginalTemplate returns the commit.template that gpair replaced with a composed template,
+// in the current repo's git config or the global one
+func GetOriginalTemplate(global bool) (string, error) {
+	return getScopedConfig(global, "gpair.originalTemplate")
+}
+
+// SetOriginalTemplate remembers the commit.template that gpair replaced, so that it can be restored
+func SetOriginalTemplate(templatePath string, global bool) error {
+	return setConfig(global, "gpair.originalTemplate", templatePath)
+}
+
+// UnsetOriginalTemplate forgets the commit.template that gpair replaced
+func UnsetOriginalTemplate(global bool) error {
+	return unsetConfig(global, "gpair.originalTemplate")
+}
+
+// RestoreTemplate replaces a gpair commit.template with the one the user had set before pairing,
+// or unsets it if there was none. A commit.template not set by gpair is left alone.
+func RestoreTemplate(global bool) error {
+	originalTemplate, err := GetOriginalTemplate(global)
+	if err != nil {
+		return err
+	}
+
+	if originalTemplate != "" {
+		err = SetTemplate(originalTemplate, global)
+		if err != nil {
+			return err
+		}
+
+		return UnsetOriginalTemplate(global)
+	}
+
+	currentTemplate, err := GetTemplate(global)
+	if err != nil {
+		return err
+	}
+
+	if currentTemplate != "" && IsGpairPath(currentTemplate) {
+		return UnsetTemplate(global)
+	}
+
+	return nil
+}
+
+// GetMaxSessionAge returns the effective gpair.maxSessionAge, the default length of a pairing session, or "" if not set
+func GetMaxSessionAge() (string, error) {
+	return getConfig("gpair.maxSessionAge")
+}
+
+// GetDefaultTrailer returns the effective gpair.trailer, the trailer that credits collaborators instead of Co-authored-by,
+// or "" if it is not set
+func GetDefaultTrailer() (string, error) {
+	return getConfig("gpair.trailer")
+}
+
+// GetConfirm returns the effective gpair.confirm, which sets when the hook asks whether you are still pairing
+func GetConfirm() (string, error) {
+	return getConfig("gpair.confirm")
+}
+
+// GetBackend returns the effective gpair backend for the current repo, defaulting to TemplateBackend
+func GetBackend() (string, error) {
+	backend, err := getConfig("gpair.backend")
+	if err != nil {
+		return "", err
+	}
+
+	if backend == "" {
+		return TemplateBackend, nil
+	}
+
+	return backend, nil
+}
+
+// SetBackend sets the gpair backend in the current repo's git config, or the global one
+func SetBackend(backend string, global bool) error {
+	return setConfig(global, "gpair.backend", backend)
+}
+
+// UnsetBackend unsets the gpair backend, reverting to the default template backend
+func UnsetBackend(global bool) error {
+	return unsetConfig(global, "gpair.backend")
+}
+
+// GetPairing returns the path of the effective pairing file used by the hook backend, or "" if there is none
+func GetPairing() (string, error) {
+	return getConfig("gpair.pairing")
+}
 
-	return strings.TrimSpace(repoName), nil
+// SetPairing points the hook backend at the pairing file at the provided filepath
+func SetPairing(pairingPath string, global bool) error {
+	return setConfig(global, "gpair.pairing", pairingPath)
 }
 
-// IsCustomTemplate returns true if git is already configured with a template not made by gpair
-func IsCustomTemplate() (bool, error) {
-	cmd := exec.Command("git", "config", "--get", "--null", "commit.template")
-	templatePathBytes, err := cmd.Output()
+// GetScopedPairing returns the pairing file set in the current repo's git config, or the global one
+func GetScopedPairing(global bool) (string, error) {
+	return getScopedConfig(global, "gpair.pairing")
+}
+
+// SetNoPairing sets an empty pairing file in the current repo's git config. It shadows the pairing of the global scope
+// or of the repo's directory, so the hook backend adds no trailers in the repo until the pairing is unset.
+func SetNoPairing() error {
+	return setConfig(false, "gpair.pairing", "")
+}
+
+// UnsetPairing unsets the pairing file used by the hook backend
+func UnsetPairing(global bool) error {
+	return unsetConfig(global, "gpair.pairing")
+}
+
+// getConfig returns the effective value of a git config key, or "" if it is not set
+func getConfig(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"config", "--get", "--null"}, args...)...)
+	valueBytes, err := cmd.Output()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			if exitErr.ExitCode() == 1 {
 				// git config exits with code 1 if the config is not set
-				return false, nil
+				return "", nil
 			}
 		}
 
-		return false, err
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(valueBytes), "\x00"), nil
+}
+
+// getScopedConfig returns the value of a git config key from the global config if global is true,
+// otherwise from the current repo's config
+func getScopedConfig(global bool, key string) (string, error) {
+	if global {
+		return getConfig("--global", key)
+	}
+
+	return getConfig(localScope, key)
+}
+
+// GetHooksPath returns the core.hooksPath set in the current repo's git config, or the global one
+// The path is expanded as git would, e.g. "~/hooks" becomes "/home/user/hooks".
+func GetHooksPath(global bool) (string, error) {
+	if global {
+		return getConfig("--path", "--global", "core.hooksPath")
 	}
 
-	templatePath := string(templatePathBytes)
+	return getConfig("--path", localScope, "core.hooksPath")
+}
 
-	return len(templatePath) > 0 && !strings.Contains(templatePath, ".gpair"), nil
+// SetHooksPath sets core.hooksPath in the current repo's git config, or the global one
+func SetHooksPath(hooksPath string, global bool) error {
+	return setConfig(global, "core.hooksPath", hooksPath)
 }
 
-// SetTemplate sets the current repo's git config commit.template to the provided filepath
-func SetTemplate(templatePath string, global bool) error {
-	cmd := exec.Command("git", gitConfig(global, "commit.template", templatePath)...)
+// UnsetHooksPath unsets core.hooksPath in the current repo's git config, or the global one
+func UnsetHooksPath(global bool) error {
+	return unsetConfig(global, "core.hooksPath")
+}
+
+func setConfig(global bool, key, value string) error {
+	cmd := exec.Command("git", gitConfig(global, key, value)...)
 	return cmd.Run()
 }
 
-// UnsetTemplate unsets the current repo's git config commit.template
-func UnsetTemplate(global bool) error {
-	cmd := exec.Command("git", gitConfig(global, "--unset", "commit.template")...)
+func unsetConfig(global bool, key string) error {
+	cmd := exec.Command("git", gitConfig(global, "--unset", key)...)
 	err := cmd.Run()
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -72,6 +384,8 @@ func gitConfig(global bool, args ...string) []string {
 	cmdString := []string{"config"}
 	if global {
 		cmdString = append(cmdString, "--global")
+	} else if localScope != "--local" {
+		cmdString = append(cmdString, localScope)
 	}
 	return append(cmdString, args...)
-}
\ No newline at end of file
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetNoPairing(t *testing.T) {
+	_, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	err := SetPairing("/tmp/gpair-global", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = SetNoPairing()
+	if err != nil {
+		t.Fatalf("SetNoPairing() error = %v", err)
+	}
+
+	if got, err := GetPairing(); err != nil || got != "" {
+		t.Errorf("GetPairing() = %q, %v, want no pairing in the repo", got, err)
+	}
+
+	if got, err := GetScopedPairing(true); err != nil || got != "/tmp/gpair-global" {
+		t.Errorf("GetScopedPairing(true) = %q, %v, want the global pairing kept", got, err)
+	}
+
+	err = UnsetPairing(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := GetPairing(); err != nil || got != "/tmp/gpair-global" {
+		t.Errorf("GetPairing() = %q, %v after unsetting the pairing of the repo, want the global pairing", got, err)
+	}
+}
+
+func TestGetRepoKey(t *testing.T) {
+	repo, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	pathKey := HashRepoKey(repo, repo)
+	if !strings.HasPrefix(pathKey, "repo-") || pathKey == HashRepoKey(repo, repo+"2") {
+		t.Fatalf("HashRepoKey() = %s, want the name of the repo and a hash of the source", pathKey)
+	}
+
+	linked := addTestWorktree(t, repo)
+	remoteURL := "https://example.com/acme/repo.git"
+
+	tests := []struct {
+		name    string
+		dir     string
+		repoKey string
+		origin  string
+		want    string
+	}{
+		{"path", repo, "", "", pathKey},
+		{"remote without an origin", repo, RemoteRepoKey, "", pathKey},
+		{"remote", repo, RemoteRepoKey, remoteURL, HashRepoKey(repo, remoteURL)},
+		{"linked worktree", linked, "", "", HashRepoKey(linked, linked)},
+		{"linked worktree with a remote", linked, RemoteRepoKey, remoteURL, HashRepoKey(linked, linked)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chdir(tt.dir); err != nil {
+				t.Fatal(err)
+			}
+			defer os.Chdir(repo)
+
+			runGit(t, "config", "--global", "gpair.repoKey", tt.repoKey)
+			if tt.origin != "" {
+				runGit(t, "remote", "add", "origin", tt.origin)
+				defer runGit(t, "remote", "remove", "origin")
+			}
+
+			got, err := GetRepoKey()
+			if err != nil || got != tt.want {
+				t.Errorf("GetRepoKey() = %s, %v, want %s", got, err, tt.want)
+			}
+		})
+	}
+}
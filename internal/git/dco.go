@@ -0,0 +1,206 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+// Styles of the trailer block that credits the coauthors of a pairing
+const (
+	// DefaultStyle credits each coauthor with a single trailer
+	DefaultStyle = ""
+	// DCOStyle follows the Linux kernel's rules for the Developer Certificate of Origin: each co-developer has
+	// a Co-developed-by trailer immediately followed by their Signed-off-by, and the submitter signs off last
+	DCOStyle = "dco"
+)
+
+// Trailers used by DCOStyle
+const (
+	CoDevelopedBy = "Co-developed-by"
+	SignedOffBy   = "Signed-off-by"
+)
+
+// ErrTrailerStyle is returned when gpair.trailerStyle is not a trailer style
+type ErrTrailerStyle struct {
+	Name string
+}
+
+func (err *ErrTrailerStyle) Error() string {
+	return fmt.Sprintf("'%s' is not a trailer style, set gpair.trailerStyle to 'default' or 'dco'", err.Name)
+}
+
+// GetTrailerStyle returns the effective gpair.trailerStyle, which is "dco" or its synonym "kernel" for DCOStyle,
+// or DefaultStyle if it is not set
+func GetTrailerStyle() (string, error) {
+	style, err := getConfig("gpair.trailerStyle")
+	if err != nil {
+		return DefaultStyle, err
+	}
+
+	return ParseTrailerStyle(style)
+}
+
+// ParseTrailerStyle returns the trailer style with the given name, accepting "kernel" for DCOStyle
+func ParseTrailerStyle(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", "default":
+		return DefaultStyle, nil
+	case DCOStyle, "kernel":
+		return DCOStyle, nil
+	}
+
+	return DefaultStyle, &ErrTrailerStyle{name}
+}
+
+// GetTrailers returns the trailers crediting the coauthors in the given style, as self
+func GetTrailers(style string, self config.Collaborator, coauthors ...config.Collaborator) []string {
+	var trailers []string
+	if style != DCOStyle {
+		for _, coauthor := range coauthors {
+			trailers = append(trailers, coauthor.String())
+		}
+
+		return trailers
+	}
+
+	var others []string
+	for _, coauthor := range coauthors {
+		if coauthor.Trailer != "" && !strings.EqualFold(coauthor.Trailer, CoDevelopedBy) {
+			// Someone credited with another trailer, such as a reviewer, is not a co-developer
+			others = append(others, coauthor.String())
+			continue
+		}
+
+		trailers = append(trailers, CoDevelopedBy+": "+identity(coauthor), SignedOffBy+": "+identity(coauthor))
+	}
+
+	trailers = append(trailers, others...)
+
+	return append(trailers, SignedOffBy+": "+identity(self))
+}
+
+// ValidateDCO checks that trailers follow the rules of DCOStyle for a commit by author, submitted by submitter,
+// who must sign off last. The author signs off but is never a co-developer. It returns a description of each problem found.
+func ValidateDCO(trailers []string, author, submitter config.Collaborator) []string {
+	var problems []string
+	var lastSignOff string
+	authorSignedOff := false
+	for i, trailer := range trailers {
+		key, value := splitTrailer(trailer)
+
+		if strings.EqualFold(key, SignedOffBy) {
+			lastSignOff = value
+			authorSignedOff = authorSignedOff || strings.EqualFold(value, identity(author))
+			continue
+		}
+
+		if !strings.EqualFold(key, CoDevelopedBy) {
+			continue
+		}
+
+		if strings.EqualFold(value, identity(author)) {
+			problems = append(problems, fmt.Sprintf("%s: %s credits the author, who must only sign off", CoDevelopedBy, value))
+		}
+
+		nextKey, nextValue := "", ""
+		if i+1 < len(trailers) {
+			nextKey, nextValue = splitTrailer(trailers[i+1])
+		}
+
+		if !strings.EqualFold(nextKey, SignedOffBy) || !strings.EqualFold(nextValue, value) {
+			problems = append(problems, fmt.Sprintf("%s: %s is not immediately followed by %s: %s", CoDevelopedBy, value, SignedOffBy, value))
+		}
+	}
+
+	if !authorSignedOff && !strings.EqualFold(identity(author), identity(submitter)) {
+		problems = append(problems, fmt.Sprintf("missing %s: %s of the author", SignedOffBy, identity(author)))
+	}
+
+	if lastSignOff == "" {
+		problems = append(problems, fmt.Sprintf("missing %s: %s", SignedOffBy, identity(submitter)))
+	} else if !strings.EqualFold(lastSignOff, identity(submitter)) {
+		problems = append(problems, fmt.Sprintf("the last %s must be the submitter, %s, not %s", SignedOffBy, identity(submitter), lastSignOff))
+	}
+
+	return problems
+}
+
+// MoveSignOffLast removes the sign-off that ends trailers from message, such as the one 'git commit -s' adds,
+// so that AppendTrailers adds it back after the trailers of the coauthors. Lines after the scissors line are left alone.
+func MoveSignOffLast(message string, trailers []string, commentChar string) string {
+	if len(trailers) == 0 {
+		return message
+	}
+
+	signOff := trailers[len(trailers)-1]
+	if key, _ := splitTrailer(signOff); !strings.EqualFold(key, SignedOffBy) {
+		return message
+	}
+
+	lines := strings.Split(message, "\n")
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if line == commentChar+scissors {
+			kept = append(kept, lines[i:]...)
+			break
+		}
+
+		if !isComment(line, commentChar) && trailersEqual(line, signOff) {
+			// Drop the paragraph break too if the sign-off was alone in its paragraph
+			next := ""
+			if i+1 < len(lines) {
+				next = lines[i+1]
+			}
+			if len(kept) > 0 && isBlank(kept[len(kept)-1]) && (isBlank(next) || isComment(next, commentChar)) {
+				kept = kept[:len(kept)-1]
+			}
+			continue
+		}
+
+		kept = append(kept, line)
+	}
+
+	return strings.Join(kept, "\n")
+}
+
+// CoauthorTrailers returns the trailers that credit coauthors, leaving out the Signed-off-by of self
+// and the Signed-off-by that follows each Co-developed-by in DCOStyle
+func CoauthorTrailers(trailers []string, self config.Collaborator) []string {
+	var coauthors []string
+	for i, trailer := range trailers {
+		key, value := splitTrailer(trailer)
+		if strings.EqualFold(key, SignedOffBy) {
+			if strings.EqualFold(value, identity(self)) {
+				continue
+			}
+
+			if i > 0 {
+				prevKey, prevValue := splitTrailer(trailers[i-1])
+				if strings.EqualFold(prevKey, CoDevelopedBy) && strings.EqualFold(prevValue, value) {
+					continue
+				}
+			}
+		}
+
+		coauthors = append(coauthors, trailer)
+	}
+
+	return coauthors
+}
+
+// identity returns the name and email of a collaborator as they appear in a trailer
+func identity(c config.Collaborator) string {
+	return fmt.Sprintf("%s <%s>", c.Name, c.Email)
+}
+
+// splitTrailer returns the key and value of a trailer line
+func splitTrailer(trailer string) (string, string) {
+	match := trailerPattern.FindStringSubmatch(strings.TrimSpace(trailer))
+	if match == nil {
+		return "", ""
+	}
+
+	return match[1], match[2]
+}
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+var (
+	me    = config.Collaborator{Name: "Me", Email: "me@example.com"}
+	alice = config.Collaborator{Alias: "alice", Name: "Alice", Email: "alice@example.com"}
+	bob   = config.Collaborator{Alias: "bob", Name: "Bob", Email: "bob@example.com"}
+)
+
+func TestGetTrailers(t *testing.T) {
+	reviewer := bob
+	reviewer.Trailer = "Reviewed-by"
+
+	tests := []struct {
+		name      string
+		style     string
+		coauthors []config.Collaborator
+		want      []string
+	}{
+		{"default", DefaultStyle, []config.Collaborator{alice, reviewer}, []string{
+			"Co-authored-by: Alice <alice@example.com>",
+			"Reviewed-by: Bob <bob@example.com>",
+		}},
+		{"dco", DCOStyle, []config.Collaborator{alice, bob}, []string{
+			"Co-developed-by: Alice <alice@example.com>",
+			"Signed-off-by: Alice <alice@example.com>",
+			"Co-developed-by: Bob <bob@example.com>",
+			"Signed-off-by: Bob <bob@example.com>",
+			"Signed-off-by: Me <me@example.com>",
+		}},
+		{"dco with reviewer", DCOStyle, []config.Collaborator{reviewer, alice}, []string{
+			"Co-developed-by: Alice <alice@example.com>",
+			"Signed-off-by: Alice <alice@example.com>",
+			"Reviewed-by: Bob <bob@example.com>",
+			"Signed-off-by: Me <me@example.com>",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetTrailers(tt.style, me, tt.coauthors...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetTrailers() = %q, want %q", got, tt.want)
+			}
+
+			if tt.style == DCOStyle {
+				if problems := ValidateDCO(got, me, me); len(problems) != 0 {
+					t.Errorf("ValidateDCO() of GetTrailers() = %q, want none", problems)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateDCO(t *testing.T) {
+	tests := []struct {
+		name     string
+		trailers []string
+		want     int
+	}{
+		{"sign-off only", []string{"Signed-off-by: Me <me@example.com>"}, 0},
+		{"missing sign-off", []string{"Co-authored-by: Alice <alice@example.com>"}, 1},
+		{"submitter not last", []string{
+			"Signed-off-by: Me <me@example.com>",
+			"Co-developed-by: Alice <alice@example.com>",
+			"Signed-off-by: Alice <alice@example.com>",
+		}, 1},
+		{"co-developer not signed off", []string{
+			"Co-developed-by: Alice <alice@example.com>",
+			"Signed-off-by: Me <me@example.com>",
+		}, 1},
+		{"sign-off not immediately after", []string{
+			"Co-developed-by: Alice <alice@example.com>",
+			"Reviewed-by: Bob <bob@example.com>",
+			"Signed-off-by: Alice <alice@example.com>",
+			"Signed-off-by: Me <me@example.com>",
+		}, 1},
+		{"author as co-developer", []string{
+			"Co-developed-by: Me <me@example.com>",
+			"Signed-off-by: Me <me@example.com>",
+		}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateDCO(tt.trailers, me, me); len(got) != tt.want {
+				t.Errorf("ValidateDCO() = %q, want %d problems", got, tt.want)
+			}
+		})
+	}
+
+	// A co-developer may submit a commit authored by someone else, who signs off first
+	submitted := []string{
+		"Signed-off-by: Me <me@example.com>",
+		"Co-developed-by: Alice <alice@example.com>",
+		"Signed-off-by: Alice <alice@example.com>",
+	}
+	if got := ValidateDCO(submitted, me, alice); len(got) != 0 {
+		t.Errorf("ValidateDCO() of a commit submitted by a co-developer = %q, want none", got)
+	}
+
+	if got := ValidateDCO(submitted[1:], me, alice); len(got) != 1 {
+		t.Errorf("ValidateDCO() without the sign-off of the author = %q, want 1 problem", got)
+	}
+}
+
+func TestMoveSignOffLast(t *testing.T) {
+	trailers := GetTrailers(DCOStyle, me, alice)
+	message := "fix bug\n\nSigned-off-by: Me <me@example.com>\n\n# Please enter the commit message\n#" + scissors + "\nSigned-off-by: Me <me@example.com>\n"
+	want := "fix bug\n\n" +
+		"Co-developed-by: Alice <alice@example.com>\n" +
+		"Signed-off-by: Alice <alice@example.com>\n" +
+		"Signed-off-by: Me <me@example.com>\n" +
+		"\n# Please enter the commit message\n#" + scissors + "\nSigned-off-by: Me <me@example.com>\n"
+
+	got := AppendTrailers(MoveSignOffLast(message, trailers, "#"), trailers, "#")
+	if got != want {
+		t.Errorf("AppendTrailers(MoveSignOffLast()) = %q, want %q", got, want)
+	}
+}
+
+func TestCoauthorTrailers(t *testing.T) {
+	trailers := []string{
+		"Co-developed-by: Alice <alice@example.com>",
+		"Signed-off-by: Alice <alice@example.com>",
+		"Reviewed-by: Bob <bob@example.com>",
+		"Signed-off-by: Me <me@example.com>",
+	}
+	want := []string{"Co-developed-by: Alice <alice@example.com>", "Reviewed-by: Bob <bob@example.com>"}
+
+	if got := CoauthorTrailers(trailers, me); !reflect.DeepEqual(got, want) {
+		t.Errorf("CoauthorTrailers() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DirPairing is a pairing for every repo in a directory, kept in a config file that the global
+// git config includes for repos in that directory
+type DirPairing struct {
+	Dir        string
+	ConfigPath string
+}
+
+// GetDirKey returns the key under which the pairing for every repo in dir is saved
+func GetDirKey(dir string) string {
+	hash := sha256.Sum256([]byte(dir))
+
+	return "dir-" + filepath.Base(dir) + "-" + hex.EncodeToString(hash[:6])
+}
+
+// GetDirConfigPath returns the path of the config file holding the pairing for every repo in dir
+func GetDirConfigPath(dir string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to locate user home directory")
+	}
+
+	return filepath.Join(home, ".gpair", "dirs", GetDirKey(dir)+".gitconfig"), nil
+}
+
+// UseConfigFile makes gpair read and write the per-repo pairing in the config file at configPath instead,
+// which is how the pairing for every repo in a directory is kept. It returns a function that switches back.
+func UseConfigFile(configPath string) func() {
+	previous := localScope
+	localScope = "--file=" + configPath
+
+	return func() {
+		localScope = previous
+	}
+}
+
+// AddDirInclude makes the global git config include the config file at configPath in every repo in dir.
+// The include is moved to the end of the global config, since git uses the last value it reads for a key,
+// and a pairing for a directory should take precedence over the global one.
+func AddDirInclude(dir, configPath string) error {
+	cmd := exec.Command("git", "config", "--global", "--null", "--get-all", includeKey(dir))
+	includesBytes, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			return err
+		}
+	}
+
+	// With --null, each path ends with a null byte, since a path may contain spaces
+	var includes []string
+	for _, include := range strings.Split(string(includesBytes), "\x00") {
+		if include != "" {
+			includes = append(includes, include)
+		}
+	}
+
+	if len(includes) == 1 && includes[0] == configPath {
+		// The section only holds the gpair include, so it can be recreated at the end
+		cmd = exec.Command("git", "config", "--global", "--remove-section", "includeIf.gitdir:"+dir+"/")
+		err = cmd.Run()
+		if err != nil {
+			return err
+		}
+	} else {
+		for _, include := range includes {
+			if include == configPath {
+				return nil
+			}
+		}
+	}
+
+	cmd = exec.Command("git", "config", "--global", "--add", includeKey(dir), configPath)
+	return cmd.Run()
+}
+
+// RemoveDirInclude removes the include of the config file at configPath for repos in dir from the global git config,
+// and deletes the file
+func RemoveDirInclude(dir, configPath string) error {
+	cmd := exec.Command("git", "config", "--global", "--unset-all", includeKey(dir), "^"+regexp.QuoteMeta(configPath)+"$")
+	err := cmd.Run()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 5 {
+			// git config exits with code 5 if the include does not exist
+			return err
+		}
+	}
+
+	err = os.Remove(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %s", configPath)
+	}
+
+	return nil
+}
+
+// GetDirPairings returns every directory pairing included by the global git config
+func GetDirPairings() ([]DirPairing, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to locate user home directory")
+	}
+	dirsPath := filepath.Join(home, ".gpair", "dirs") + string(filepath.Separator)
+
+	cmd := exec.Command("git", "config", "--global", "--null", "--get-regexp", `^includeif\.gitdir:.*\.path$`)
+	includesBytes, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			// git config exits with code 1 if nothing matches
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var pairings []DirPairing
+	for _, entry := range strings.Split(string(includesBytes), "\x00") {
+		// With --null, each entry is the key and the value separated by a newline
+		parts := strings.SplitN(entry, "\n", 2)
+		if len(parts) < 2 || !strings.HasPrefix(parts[1], dirsPath) {
+			continue
+		}
+
+		dir := strings.TrimSuffix(strings.TrimPrefix(parts[0], "includeif.gitdir:"), ".path")
+		pairings = append(pairings, DirPairing{strings.TrimSuffix(dir, "/"), parts[1]})
+	}
+
+	return pairings, nil
+}
+
+// IsInDir returns true if the current repo is in dir, so that the include for dir applies to it
+func IsInDir(dir string) (bool, error) {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir")
+	gitDirBytes, err := cmd.Output()
+	if err != nil {
+		return false, err
+	}
+
+	return strings.HasPrefix(strings.TrimSpace(string(gitDirBytes)), dir+"/"), nil
+}
+
+func includeKey(dir string) string {
+	return "includeIf.gitdir:" + dir + "/.path"
+}
@@ -0,0 +1,182 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddDirInclude(t *testing.T) {
+	repo, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	dir := filepath.Dir(repo)
+	home := os.Getenv("HOME")
+	configPath := filepath.Join(home, ".gpair", "dirs", GetDirKey(dir)+".gitconfig")
+	otherPath := filepath.Join(home, "other.gitconfig")
+	spacedPath := filepath.Join(home, "My Dirs", GetDirKey(dir)+".gitconfig")
+	section := `[includeIf "gitdir:` + dir + `/"]` + "\n"
+
+	for _, path := range []string{configPath, spacedPath} {
+		err := os.MkdirAll(filepath.Dir(path), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ioutil.WriteFile(path, []byte("[gpair]\n\tscope = dir\n"), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		configPath string
+		global     string
+		want       []string
+	}{
+		{
+			"no global config",
+			configPath,
+			"",
+			[]string{includeKey(dir) + "=" + configPath},
+		},
+		{
+			"after the global pairing",
+			configPath,
+			"[gpair]\n\tscope = global\n",
+			[]string{"gpair.scope=global", includeKey(dir) + "=" + configPath},
+		},
+		{
+			"moved after the global pairing",
+			configPath,
+			section + "\tpath = " + configPath + "\n[gpair]\n\tscope = global\n",
+			[]string{"gpair.scope=global", includeKey(dir) + "=" + configPath},
+		},
+		{
+			"already last",
+			configPath,
+			"[gpair]\n\tscope = global\n" + section + "\tpath = " + configPath + "\n",
+			[]string{"gpair.scope=global", includeKey(dir) + "=" + configPath},
+		},
+		{
+			"added to a section with another include",
+			configPath,
+			section + "\tpath = " + otherPath + "\n",
+			[]string{includeKey(dir) + "=" + otherPath, includeKey(dir) + "=" + configPath},
+		},
+		{
+			"not duplicated in a section with another include",
+			configPath,
+			section + "\tpath = " + otherPath + "\n\tpath = " + configPath + "\n",
+			[]string{includeKey(dir) + "=" + otherPath, includeKey(dir) + "=" + configPath},
+		},
+		{
+			"path with a space moved after the global pairing",
+			spacedPath,
+			section + "\tpath = " + spacedPath + "\n[gpair]\n\tscope = global\n",
+			[]string{"gpair.scope=global", includeKey(dir) + "=" + spacedPath},
+		},
+		{
+			"path with a space already last",
+			spacedPath,
+			"[gpair]\n\tscope = global\n" + section + "\tpath = " + spacedPath + "\n",
+			[]string{"gpair.scope=global", includeKey(dir) + "=" + spacedPath},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ioutil.WriteFile(filepath.Join(home, ".gitconfig"), []byte(tt.global), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Adding the include again must leave the config as it is
+			for i := 0; i < 2; i++ {
+				err = AddDirInclude(dir, tt.configPath)
+				if err != nil {
+					t.Fatalf("AddDirInclude() error = %v", err)
+				}
+			}
+
+			// git lowercases the section name, but not the subsection
+			var got []string
+			for _, line := range strings.Split(strings.TrimSpace(runGit(t, "config", "--global", "--list")), "\n") {
+				got = append(got, strings.Replace(line, "includeif.", "includeIf.", 1))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AddDirInclude() global config = %v, want %v", got, tt.want)
+			}
+
+			if scope := strings.TrimSpace(runGit(t, "config", "gpair.scope")); scope != "dir" {
+				t.Errorf("AddDirInclude() effective gpair.scope = %s, want dir", scope)
+			}
+		})
+	}
+}
+
+func TestGetDirPairings(t *testing.T) {
+	_, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	home := os.Getenv("HOME")
+	dirsPath := filepath.Join(home, ".gpair", "dirs")
+	work := filepath.Join(home, "work")
+	oss := filepath.Join(home, "src", "oss projects")
+	workPath := filepath.Join(dirsPath, GetDirKey(work)+".gitconfig")
+	ossPath := filepath.Join(dirsPath, GetDirKey(oss)+".gitconfig")
+
+	tests := []struct {
+		name   string
+		global string
+		want   []DirPairing
+	}{
+		{
+			"no global config",
+			"",
+			nil,
+		},
+		{
+			"no includes",
+			"[gpair]\n\tscope = global\n",
+			nil,
+		},
+		{
+			"one pairing",
+			`[includeIf "gitdir:` + work + `/"]` + "\n\tpath = " + workPath + "\n",
+			[]DirPairing{{work, workPath}},
+		},
+		{
+			"pairings in order, with spaces in the dir",
+			`[includeIf "gitdir:` + work + `/"]` + "\n\tpath = " + workPath + "\n" +
+				`[includeIf "gitdir:` + oss + `/"]` + "\n\tpath = \"" + ossPath + "\"\n",
+			[]DirPairing{{work, workPath}, {oss, ossPath}},
+		},
+		{
+			"includes not made by gpair are skipped",
+			`[includeIf "gitdir:` + work + `/"]` + "\n\tpath = " + filepath.Join(home, "work.gitconfig") + "\n" +
+				`[includeIf "onbranch:main"]` + "\n\tpath = " + workPath + "\n" +
+				"[include]\n\tpath = " + ossPath + "\n" +
+				`[includeIf "gitdir:` + oss + `/"]` + "\n\tpath = " + ossPath + "\n",
+			[]DirPairing{{oss, ossPath}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ioutil.WriteFile(filepath.Join(home, ".gitconfig"), []byte(tt.global), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := GetDirPairings()
+			if err != nil {
+				t.Fatalf("GetDirPairings() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetDirPairings() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
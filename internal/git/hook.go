@@ -0,0 +1,381 @@
+package git
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Hooks that gpair can install to add co-author trailers to commit messages
+const (
+	PrepareCommitMsgHook = "prepare-commit-msg"
+	CommitMsgHook        = "commit-msg"
+	PostCheckoutHook     = "post-checkout"
+)
+
+// Hook managers that gpair knows how to integrate with
+const (
+	Husky     = "husky"
+	Lefthook  = "lefthook"
+	PreCommit = "pre-commit"
+)
+
+// hookMarker identifies hook scripts written by gpair
+const hookMarker = "# Installed by gpair."
+
+// chainedSuffix is appended to the name of an existing hook when gpair wraps it
+const chainedSuffix = ".pre-gpair"
+
+// clientHooks are the hooks git runs in a working copy, which the global gpair hooks directory passes through
+// to each repo's own hooks so that pointing core.hooksPath at it does not disable them
+var clientHooks = []string{
+	"applypatch-msg", "pre-applypatch", "post-applypatch", "pre-commit", "pre-merge-commit",
+	PrepareCommitMsgHook, CommitMsgHook, "post-commit", "pre-rebase", "post-checkout", "post-merge",
+	"pre-push", "pre-auto-gc", "post-rewrite", "push-to-checkout", "sendemail-validate", "post-index-change",
+}
+
+// ErrHookExists is returned when a hook not installed by gpair is already present
+type ErrHookExists struct {
+	Path string
+}
+
+func (err *ErrHookExists) Error() string {
+	return fmt.Sprintf("a hook not installed by gpair already exists at %s", err.Path)
+}
+
+// IsSupportedHook returns true if gpair knows how to run as the named hook
+func IsSupportedHook(name string) bool {
+	return IsMessageHook(name) || name == PostCheckoutHook
+}
+
+// IsMessageHook returns true if the named hook is one gpair can add co-author trailers from
+func IsMessageHook(name string) bool {
+	return name == PrepareCommitMsgHook || name == CommitMsgHook
+}
+
+// GetHooksDir returns the absolute path of the hooks directory of the current repo.
+// This respects core.hooksPath.
+func GetHooksDir() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	hooksPathBytes, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Abs(strings.TrimSpace(string(hooksPathBytes)))
+}
+
+// GetGlobalHooksDir returns the directory gpair uses as core.hooksPath when hooks are installed globally
+func GetGlobalHooksDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to locate user home directory")
+	}
+
+	return filepath.Join(home, ".gpair", "hooks"), nil
+}
+
+// DetectHookManager returns the name of the tool managing the named hook of the repo at repoRoot, or "" if there is none.
+// Hooks written into a directory owned by a hook manager would be overwritten or ignored by it.
+// lefthook and pre-commit only install the hooks they are configured for, so they only manage a hook they have written.
+func DetectHookManager(repoRoot, hooksDir, name string) string {
+	scriptBytes, _ := ioutil.ReadFile(filepath.Join(hooksDir, name))
+	script := string(scriptBytes)
+
+	rel, err := filepath.Rel(repoRoot, hooksDir)
+	if err == nil && (rel == ".husky" || strings.HasPrefix(rel, ".husky"+string(filepath.Separator))) {
+		return Husky
+	}
+
+	if strings.Contains(script, "husky") {
+		return Husky
+	}
+
+	if strings.Contains(script, "lefthook") {
+		return Lefthook
+	}
+
+	if strings.Contains(script, "File generated by pre-commit") || strings.Contains(script, "pre-commit.com") {
+		return PreCommit
+	}
+
+	return ""
+}
+
+// HookManagerRunsGpair returns true if the hook manager already runs gpair as the named hook,
+// because the snippet from HookManagerSnippet has been added to its configuration
+func HookManagerRunsGpair(repoRoot, hooksDir, manager, name string) bool {
+	return hookManagerRuns(repoRoot, hooksDir, manager, name, "gpair hook run "+name)
+}
+
+// HookManagerRunsMentions returns true if the hook manager already runs gpair as a commit-msg hook
+// that expands @alias mentions, because the snippet from MentionsHookSnippet has been added to its configuration
+func HookManagerRunsMentions(repoRoot, hooksDir, manager string) bool {
+	return hookManagerRuns(repoRoot, hooksDir, manager, CommitMsgHook, "gpair hook run "+MentionsOnlyFlag+" "+CommitMsgHook)
+}
+
+// hookManagerRuns returns true if the configuration of the hook manager for the named hook contains the command
+func hookManagerRuns(repoRoot, hooksDir, manager, name, command string) bool {
+	var paths []string
+	switch manager {
+	case Husky:
+		paths = []string{filepath.Join(hooksDir, name), filepath.Join(repoRoot, ".husky", name)}
+	case Lefthook:
+		for _, filename := range []string{"lefthook.yml", ".lefthook.yml", "lefthook.yaml", ".lefthook.yaml"} {
+			paths = append(paths, filepath.Join(repoRoot, filename))
+		}
+	case PreCommit:
+		paths = []string{filepath.Join(repoRoot, ".pre-commit-config.yaml")}
+	}
+
+	for _, path := range paths {
+		configBytes, err := ioutil.ReadFile(path)
+		if err == nil && strings.Contains(string(configBytes), command) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MentionsOnlyFlag makes 'gpair hook run commit-msg' only expand @alias mentions, for the commit-msg hook
+// gpair installs alongside a prepare-commit-msg hook that adds the co-author trailers
+const MentionsOnlyFlag = "-mentions-only"
+
+// HookManagerSnippet returns the instructions for running gpair as the named hook from a hook manager
+func HookManagerSnippet(manager, name string) string {
+	return hookManagerSnippet(manager, name, "")
+}
+
+// MentionsHookSnippet returns the instructions for running gpair as a commit-msg hook that only expands @alias mentions
+func MentionsHookSnippet(manager string) string {
+	return hookManagerSnippet(manager, CommitMsgHook, MentionsOnlyFlag+" ")
+}
+
+func hookManagerSnippet(manager, name, flags string) string {
+	switch manager {
+	case Husky:
+		return fmt.Sprintf("Add the following line to .husky/%s:\n\n"+
+			"gpair hook run %s%s \"$@\"\n", name, flags, name)
+
+	case Lefthook:
+		return fmt.Sprintf("Add the following to lefthook.yml, then run 'lefthook install':\n\n"+
+			"%s:\n"+
+			"  commands:\n"+
+			"    gpair:\n"+
+			"      run: gpair hook run %s%s {1} {2} {3}\n", name, flags, name)
+
+	case PreCommit:
+		return fmt.Sprintf("Add the following to .pre-commit-config.yaml, then run 'pre-commit install --hook-type %s':\n\n"+
+			"- repo: local\n"+
+			"  hooks:\n"+
+			"    - id: gpair\n"+
+			"      name: gpair\n"+
+			"      entry: gpair hook run %s%s\n"+
+			"      language: system\n"+
+			"      always_run: true\n"+
+			"      stages: [%s]\n", name, flags, name, name)
+	}
+
+	return fmt.Sprintf("Add the following line to your %s hook:\n\n"+
+		"gpair hook run %s%s \"$@\"\n", name, flags, name)
+}
+
+// InstallHook writes a gpair hook script with the given name into hooksDir.
+// An existing hook not written by gpair is kept and run by the gpair hook before it adds the trailers.
+// If chainLocal is true, the script also runs the hook of the same name in the repo's .git/hooks,
+// which git would otherwise skip when hooksDir is used as core.hooksPath.
+func InstallHook(hooksDir, name string, chainLocal bool) (string, error) {
+	return installHook(hooksDir, name, "hook run "+name, chainLocal)
+}
+
+// InstallMentionsHook writes a gpair commit-msg hook script into hooksDir that only expands @alias mentions.
+// It is installed alongside a prepare-commit-msg hook, which runs before the message is edited.
+func InstallMentionsHook(hooksDir string, chainLocal bool) (string, error) {
+	return installHook(hooksDir, CommitMsgHook, "hook run "+MentionsOnlyFlag+" "+CommitMsgHook, chainLocal)
+}
+
+// installHook writes a hook script with the given name into hooksDir, which runs gpair with the given arguments
+func installHook(hooksDir, name, gpairArgs string, chainLocal bool) (string, error) {
+	hookPath := filepath.Join(hooksDir, name)
+
+	err := os.MkdirAll(hooksDir, 0700)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create hooks directory %s", hooksDir)
+	}
+
+	err = chainExistingHook(hookPath)
+	if err != nil {
+		return "", err
+	}
+
+	err = ioutil.WriteFile(hookPath, []byte(hookScript(name, gpairArgs, chainLocal)), 0755)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write hook %s", hookPath)
+	}
+
+	return hookPath, nil
+}
+
+// InstallPassthroughHooks writes a script into hooksDir for every other client hook than the named one,
+// which runs the repo's own hook of the same name. The post-checkout hook also runs gpair, to follow per-branch pairings.
+func InstallPassthroughHooks(hooksDir, name string) error {
+	for _, passthrough := range clientHooks {
+		if passthrough == name {
+			continue
+		}
+
+		hookPath := filepath.Join(hooksDir, passthrough)
+
+		err := chainExistingHook(hookPath)
+		if err != nil {
+			return err
+		}
+
+		gpairArgs := ""
+		if passthrough == PostCheckoutHook {
+			gpairArgs = "hook run " + passthrough
+		}
+
+		err = ioutil.WriteFile(hookPath, []byte(hookScript(passthrough, gpairArgs, true)), 0755)
+		if err != nil {
+			return errors.Wrapf(err, "failed to write hook %s", hookPath)
+		}
+	}
+
+	return nil
+}
+
+// UninstallHook removes the gpair hook script with the given name from hooksDir,
+// and puts back the hook it wrapped, if any. Hooks not installed by gpair are left alone.
+func UninstallHook(hooksDir, name string) (bool, error) {
+	hookPath := filepath.Join(hooksDir, name)
+
+	isGpair, err := IsGpairHook(hookPath)
+	if err != nil || !isGpair {
+		return false, err
+	}
+
+	err = os.Remove(hookPath)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to remove hook %s", hookPath)
+	}
+
+	_, err = os.Stat(hookPath + chainedSuffix)
+	if err == nil {
+		err = os.Rename(hookPath+chainedSuffix, hookPath)
+		if err != nil {
+			return true, errors.Wrapf(err, "failed to restore hook %s%s", hookPath, chainedSuffix)
+		}
+	}
+
+	return true, nil
+}
+
+// UninstallAllHooks removes every gpair hook script from hooksDir, including passthrough hooks
+func UninstallAllHooks(hooksDir string) ([]string, error) {
+	var removed []string
+	for _, name := range clientHooks {
+		wasRemoved, err := UninstallHook(hooksDir, name)
+		if err != nil {
+			return removed, err
+		}
+
+		if wasRemoved {
+			removed = append(removed, name)
+		}
+	}
+
+	return removed, nil
+}
+
+// IsGpairHook returns true if the file at hookPath is a hook script written by gpair
+func IsGpairHook(hookPath string) (bool, error) {
+	scriptBytes, err := ioutil.ReadFile(hookPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+
+		return false, errors.Wrapf(err, "failed to read hook %s", hookPath)
+	}
+
+	return strings.Contains(string(scriptBytes), hookMarker), nil
+}
+
+// chainExistingHook moves a hook not written by gpair out of the way, so the gpair hook can run it
+func chainExistingHook(hookPath string) error {
+	isGpair, err := IsGpairHook(hookPath)
+	if err != nil || isGpair {
+		return err
+	}
+
+	if _, err := os.Stat(hookPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	if _, err := os.Stat(hookPath + chainedSuffix); err == nil {
+		// Don't overwrite a hook that was chained before
+		return &ErrHookExists{hookPath}
+	}
+
+	err = os.Rename(hookPath, hookPath+chainedSuffix)
+	if err != nil {
+		return errors.Wrapf(err, "failed to move existing hook %s", hookPath)
+	}
+
+	return nil
+}
+
+// hookScript returns a hook script that runs the hook it replaced, then gpair with the given arguments, if any
+func hookScript(name, gpairArgs string, chainLocal bool) string {
+	script := "#!/bin/sh\n" +
+		"# gpair " + name + " hook\n" +
+		hookMarker + " Remove it with 'gpair hook uninstall'.\n"
+
+	// Each command would otherwise read the input of the hook before the next, so read it once and give it to each
+	run := ""
+	if readsStdin(name) {
+		script += "hook_input=\"$(cat)\"\n" +
+			"replay_input() {\n" +
+			"\tif [ -n \"$hook_input\" ]; then printf '%s\\n' \"$hook_input\"; fi\n" +
+			"}\n"
+		run = "replay_input | "
+	}
+
+	script += "previous_hook=\"$(dirname \"$0\")/" + name + chainedSuffix + "\"\n" +
+		"if [ -x \"$previous_hook\" ]; then\n" +
+		"\t" + run + "\"$previous_hook\" \"$@\" || exit $?\n" +
+		"fi\n"
+
+	if gpairArgs != "" {
+		script += "if command -v gpair >/dev/null 2>&1; then\n" +
+			"\t" + run + "gpair " + gpairArgs + " \"$@\" || exit $?\n" +
+			"fi\n"
+	}
+
+	if chainLocal {
+		// The hooks of a linked worktree are those of the main working tree, in the common git directory
+		script += "local_hook=\"$(git rev-parse --git-common-dir)/hooks/" + name + "\"\n" +
+			"if [ -x \"$local_hook\" ]; then\n"
+		if run != "" {
+			script += "\t" + run + "\"$local_hook\" \"$@\"\n" +
+				"\texit $?\n"
+		} else {
+			script += "\texec \"$local_hook\" \"$@\"\n"
+		}
+		script += "fi\n"
+	}
+
+	return script
+}
+
+// readsStdin returns true if git passes the input of the named hook on stdin
+func readsStdin(name string) bool {
+	return name == "pre-push" || name == "post-rewrite"
+}
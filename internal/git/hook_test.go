@@ -0,0 +1,291 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallUninstallHook(t *testing.T) {
+	hooksDir, err := ioutil.TempDir("", "gpair_hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(hooksDir)
+
+	hookPath := filepath.Join(hooksDir, PrepareCommitMsgHook)
+	existing := "#!/bin/sh\necho existing\n"
+	err = ioutil.WriteFile(hookPath, []byte(existing), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = InstallHook(hooksDir, PrepareCommitMsgHook, false)
+	if err != nil {
+		t.Fatalf("InstallHook() error = %v", err)
+	}
+
+	if isGpair, _ := IsGpairHook(hookPath); !isGpair {
+		t.Errorf("InstallHook() did not write a gpair hook to %s", hookPath)
+	}
+
+	chainedBytes, err := ioutil.ReadFile(hookPath + chainedSuffix)
+	if err != nil || string(chainedBytes) != existing {
+		t.Errorf("InstallHook() did not keep the existing hook, got %q, error = %v", chainedBytes, err)
+	}
+
+	// Installing again must not chain the gpair hook to itself
+	_, err = InstallHook(hooksDir, PrepareCommitMsgHook, false)
+	if err != nil {
+		t.Fatalf("InstallHook() second install error = %v", err)
+	}
+
+	removed, err := UninstallHook(hooksDir, PrepareCommitMsgHook)
+	if err != nil || !removed {
+		t.Fatalf("UninstallHook() = %v, error = %v", removed, err)
+	}
+
+	restoredBytes, err := ioutil.ReadFile(hookPath)
+	if err != nil || string(restoredBytes) != existing {
+		t.Errorf("UninstallHook() did not restore the existing hook, got %q, error = %v", restoredBytes, err)
+	}
+
+	removed, err = UninstallHook(hooksDir, PrepareCommitMsgHook)
+	if err != nil || removed {
+		t.Errorf("UninstallHook() removed a hook not installed by gpair, error = %v", err)
+	}
+}
+
+func TestInstallMentionsHook(t *testing.T) {
+	hooksDir, err := ioutil.TempDir("", "gpair_hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(hooksDir)
+
+	hookPath, err := InstallMentionsHook(hooksDir, false)
+	if err != nil {
+		t.Fatalf("InstallMentionsHook() error = %v", err)
+	}
+
+	if hookPath != filepath.Join(hooksDir, CommitMsgHook) {
+		t.Errorf("InstallMentionsHook() wrote %s, want a %s hook", hookPath, CommitMsgHook)
+	}
+
+	scriptBytes, err := ioutil.ReadFile(hookPath)
+	if err != nil || !strings.Contains(string(scriptBytes), "gpair hook run "+MentionsOnlyFlag+" "+CommitMsgHook+" \"$@\"") {
+		t.Errorf("InstallMentionsHook() wrote %q, want it to only expand mentions, error = %v", scriptBytes, err)
+	}
+}
+
+func TestDetectHookManager(t *testing.T) {
+	repoRoot, err := ioutil.TempDir("", "gpair_repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoRoot)
+
+	gitHooks := filepath.Join(repoRoot, ".git", "hooks")
+	err = os.MkdirAll(gitHooks, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := DetectHookManager(repoRoot, gitHooks, PrepareCommitMsgHook); got != "" {
+		t.Errorf("DetectHookManager() = %s, want none", got)
+	}
+
+	if got := DetectHookManager(repoRoot, filepath.Join(repoRoot, ".husky", "_"), PrepareCommitMsgHook); got != Husky {
+		t.Errorf("DetectHookManager() = %s, want %s", got, Husky)
+	}
+
+	// A configured manager only manages the hooks it has installed
+	for _, config := range []string{"lefthook.yml", ".pre-commit-config.yaml"} {
+		err = ioutil.WriteFile(filepath.Join(repoRoot, config), []byte{}, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := DetectHookManager(repoRoot, gitHooks, PrepareCommitMsgHook); got != "" {
+		t.Errorf("DetectHookManager() = %s, want none", got)
+	}
+
+	stubs := map[string]string{
+		"pre-commit":         "#!/usr/bin/env bash\n# File generated by pre-commit: https://pre-commit.com\n",
+		PrepareCommitMsgHook: "#!/bin/sh\nlefthook run prepare-commit-msg \"$@\"\n",
+	}
+	for hook, stub := range stubs {
+		err = ioutil.WriteFile(filepath.Join(gitHooks, hook), []byte(stub), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := DetectHookManager(repoRoot, gitHooks, PrepareCommitMsgHook); got != Lefthook {
+		t.Errorf("DetectHookManager() = %s, want %s", got, Lefthook)
+	}
+
+	if got := DetectHookManager(repoRoot, gitHooks, "pre-commit"); got != PreCommit {
+		t.Errorf("DetectHookManager() = %s, want %s", got, PreCommit)
+	}
+
+	if got := DetectHookManager(repoRoot, gitHooks, CommitMsgHook); got != "" {
+		t.Errorf("DetectHookManager() = %s, want none", got)
+	}
+}
+
+func TestHookManagerRunsGpair(t *testing.T) {
+	repoRoot, err := ioutil.TempDir("", "gpair_repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(repoRoot)
+
+	configPath := filepath.Join(repoRoot, ".pre-commit-config.yaml")
+	err = ioutil.WriteFile(configPath, []byte("repos: []\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if HookManagerRunsGpair(repoRoot, repoRoot, PreCommit, PrepareCommitMsgHook) {
+		t.Errorf("HookManagerRunsGpair() = true before the snippet was added")
+	}
+
+	err = ioutil.WriteFile(configPath, []byte(HookManagerSnippet(PreCommit, PrepareCommitMsgHook)), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !HookManagerRunsGpair(repoRoot, repoRoot, PreCommit, PrepareCommitMsgHook) {
+		t.Errorf("HookManagerRunsGpair() = false after the snippet was added")
+	}
+
+	if HookManagerRunsMentions(repoRoot, repoRoot, PreCommit) {
+		t.Errorf("HookManagerRunsMentions() = true without the mentions snippet")
+	}
+}
+
+func TestInstallPassthroughHooks(t *testing.T) {
+	hooksDir, err := ioutil.TempDir("", "gpair_hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(hooksDir)
+
+	err = InstallPassthroughHooks(hooksDir, PrepareCommitMsgHook)
+	if err != nil {
+		t.Fatalf("InstallPassthroughHooks() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(hooksDir, PrepareCommitMsgHook)); !os.IsNotExist(err) {
+		t.Errorf("InstallPassthroughHooks() wrote the %s hook it should skip", PrepareCommitMsgHook)
+	}
+
+	for _, name := range []string{CommitMsgHook, PostCheckoutHook, "pre-commit"} {
+		scriptBytes, err := ioutil.ReadFile(filepath.Join(hooksDir, name))
+		if err != nil {
+			t.Fatalf("InstallPassthroughHooks() did not write the %s hook, error = %v", name, err)
+		}
+
+		runsGpair := strings.Contains(string(scriptBytes), "gpair hook run")
+		if runsGpair != (name == PostCheckoutHook) {
+			t.Errorf("InstallPassthroughHooks() %s hook runs gpair = %v", name, runsGpair)
+		}
+	}
+}
+
+func TestPassthroughHookRunsLocalHook(t *testing.T) {
+	repo, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	linked := addTestWorktree(t, repo)
+
+	hooksDir, err := ioutil.TempDir("", "gpair_hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(hooksDir)
+
+	err = InstallPassthroughHooks(hooksDir, PrepareCommitMsgHook)
+	if err != nil {
+		t.Fatalf("InstallPassthroughHooks() error = %v", err)
+	}
+
+	marker := filepath.Join(hooksDir, "ran")
+	localHook := "#!/bin/sh\necho \"$@\" > \"" + marker + "\"\n"
+	err = ioutil.WriteFile(filepath.Join(repo, ".git", "hooks", "post-commit"), []byte(localHook), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The hooks of the main working tree also apply to its linked worktrees
+	for _, dir := range []string{repo, linked} {
+		os.Remove(marker)
+
+		cmd := exec.Command(filepath.Join(hooksDir, "post-commit"), "arg")
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("post-commit hook in %s error = %v, output = %s", dir, err, output)
+		}
+
+		got, err := ioutil.ReadFile(marker)
+		if err != nil {
+			t.Fatalf("post-commit hook in %s did not run the repo's own hook", dir)
+		}
+		if string(got) != "arg\n" {
+			t.Errorf("post-commit hook in %s ran the repo's own hook with %q, want %q", dir, got, "arg\n")
+		}
+	}
+}
+
+func TestPassthroughHookReplaysStdin(t *testing.T) {
+	repo, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	hooksDir, err := ioutil.TempDir("", "gpair_hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(hooksDir)
+
+	// A hook already in hooksDir is chained, and runs before the repo's own hook
+	previousInput := filepath.Join(hooksDir, "previous")
+	err = ioutil.WriteFile(filepath.Join(hooksDir, "pre-push"), []byte("#!/bin/sh\ncat > \""+previousInput+"\"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = InstallPassthroughHooks(hooksDir, PrepareCommitMsgHook)
+	if err != nil {
+		t.Fatalf("InstallPassthroughHooks() error = %v", err)
+	}
+
+	localInput := filepath.Join(hooksDir, "local")
+	err = ioutil.WriteFile(filepath.Join(repo, ".git", "hooks", "pre-push"), []byte("#!/bin/sh\ncat > \""+localInput+"\"\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "refs/heads/main 1111 refs/heads/main 2222\nrefs/heads/dev 3333 refs/heads/dev 4444\n"
+	cmd := exec.Command(filepath.Join(hooksDir, "pre-push"), "origin", "https://example.com/repo.git")
+	cmd.Stdin = strings.NewReader(input)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("pre-push hook error = %v, output = %s", err, output)
+	}
+
+	for _, path := range []string{previousInput, localInput} {
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("pre-push hook did not run %s, error = %v", filepath.Base(path), err)
+		}
+		if string(got) != input {
+			t.Errorf("pre-push hook gave the %s hook %q, want %q", filepath.Base(path), got, input)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+// Separators of the fields and records in the output of getCommits, which cannot appear in a commit message
+const (
+	fieldSeparator  = "\x1f"
+	recordSeparator = "\x1e"
+)
+
+// commitFormat is the git log format of a Commit, with the author and committer mapped through .mailmap
+const commitFormat = "%H%x1f%aN%x1f%aE%x1f%cN%x1f%cE%x1f%at%x1f%B%x1e"
+
+// Commit is a commit read from the git log
+type Commit struct {
+	SHA       string
+	Author    config.Collaborator
+	Committer config.Collaborator
+	Time      time.Time
+	Message   string
+}
+
+// Subject returns the first line of the commit message
+func (c Commit) Subject() string {
+	return strings.SplitN(c.Message, "\n", 2)[0]
+}
+
+// ErrRevisionRange is returned when git cannot resolve a revision range
+type ErrRevisionRange struct {
+	Range  string
+	Reason string
+}
+
+func (err *ErrRevisionRange) Error() string {
+	return fmt.Sprintf("'%s' is not a valid revision range: %s", err.Range, err.Reason)
+}
+
+// GetCommits returns the commits in a revision range, such as 'main..HEAD', newest first.
+// Extra arguments are passed on to git log, such as '--since=7d'.
+func GetCommits(revRange string, args ...string) ([]Commit, error) {
+	gitArgs := append([]string{"log", "--format=" + commitFormat}, args...)
+	gitArgs = append(gitArgs, revRange, "--")
+
+	cmd := exec.Command("git", gitArgs...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, &ErrRevisionRange{revRange, strings.TrimSpace(stderr.String())}
+		}
+
+		return nil, err
+	}
+
+	return parseCommits(string(out)), nil
+}
+
+// parseCommits parses the output of git log in commitFormat
+func parseCommits(out string) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(out, recordSeparator) {
+		fields := strings.Split(strings.TrimLeft(record, "\n"), fieldSeparator)
+		if len(fields) != 7 {
+			continue
+		}
+
+		seconds, _ := strconv.ParseInt(fields[5], 10, 64)
+		commits = append(commits, Commit{
+			SHA:       fields[0],
+			Author:    config.Collaborator{Name: fields[1], Email: fields[2]},
+			Committer: config.Collaborator{Name: fields[3], Email: fields[4]},
+			Time:      time.Unix(seconds, 0),
+			Message:   fields[6],
+		})
+	}
+
+	return commits
+}
@@ -0,0 +1,24 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestParseCommits(t *testing.T) {
+	out := "abc\x1fAlice\x1falice@example.com\x1fBob\x1fbob@example.com\x1f1600000000\x1ffix bug\n\nCo-authored-by: Bob <bob@example.com>\n\x1e\n" +
+		"def\x1fBob\x1fbob@example.com\x1fBob\x1fbob@example.com\x1f1500000000\x1fadd feature\n\x1e\n"
+
+	commits := parseCommits(out)
+	if len(commits) != 2 {
+		t.Fatalf("parseCommits() returned %d commits, want 2", len(commits))
+	}
+
+	first := commits[0]
+	if first.SHA != "abc" || first.Author.Email != "alice@example.com" || first.Committer.Name != "Bob" || first.Time.Unix() != 1600000000 {
+		t.Errorf("parseCommits() first commit = %+v", first)
+	}
+
+	if first.Subject() != "fix bug" || commits[1].Subject() != "add feature" {
+		t.Errorf("Subject() = %q, %q, want %q, %q", first.Subject(), commits[1].Subject(), "fix bug", "add feature")
+	}
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+// identityPattern matches a name and email as git check-mailmap prints them
+var identityPattern = regexp.MustCompile(`^(.*?)\s*<([^>]*)>$`)
+
+// MapIdentities returns the canonical name and email of each identity according to the repo's .mailmap,
+// in the same order. Identities that .mailmap does not mention are returned as they are.
+func MapIdentities(identities []config.Collaborator) ([]config.Collaborator, error) {
+	if len(identities) == 0 {
+		return nil, nil
+	}
+
+	// Look each identity up once, since a long history repeats the same few. They are passed on stdin,
+	// since a long history can have more identities than fit on a command line.
+	var input strings.Builder
+	index := make(map[string]int)
+	for _, identity := range identities {
+		line := fmt.Sprintf("%s <%s>", identity.Name, identity.Email)
+		if _, ok := index[line]; !ok {
+			index[line] = len(index)
+			input.WriteString(line + "\n")
+		}
+	}
+
+	cmd := exec.Command("git", "check-mailmap", "--stdin")
+	cmd.Stdin = strings.NewReader(input.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up identities in .mailmap: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(lines) != len(index) {
+		return nil, fmt.Errorf("git check-mailmap returned %d identities instead of %d", len(lines), len(index))
+	}
+
+	mapped := make([]config.Collaborator, len(identities))
+	for i, identity := range identities {
+		mapped[i] = identity
+		line := lines[index[fmt.Sprintf("%s <%s>", identity.Name, identity.Email)]]
+		if match := identityPattern.FindStringSubmatch(line); match != nil {
+			mapped[i].Name, mapped[i].Email = match[1], match[2]
+		}
+	}
+
+	return mapped, nil
+}
+
+// GetGitPath returns the absolute path of a file in the git directory of the current repo, such as SQUASH_MSG
+func GetGitPath(name string) (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--git-path", name).Output()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Abs(strings.TrimSpace(string(out)))
+}
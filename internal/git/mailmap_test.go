@@ -0,0 +1,43 @@
+package git
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+func TestMapIdentities(t *testing.T) {
+	_, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	err := ioutil.WriteFile(".mailmap", []byte("Bob <bob@example.com> Bobby <bobby@old.com>\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bob := config.Collaborator{Name: "Bob", Email: "bob@example.com"}
+	bobby := config.Collaborator{Name: "Bobby", Email: "bobby@old.com"}
+	alice := config.Collaborator{Name: "Alice", Email: "alice@example.com"}
+
+	identities := []config.Collaborator{bobby, alice, bobby, {Name: "Nobody"}}
+	want := []config.Collaborator{bob, alice, bob, {Name: "Nobody"}}
+
+	// More distinct identities than fit on a command line
+	for i := 0; i < 100000; i++ {
+		identity := config.Collaborator{Name: fmt.Sprintf("Person %d", i), Email: fmt.Sprintf("person%d@example.com", i)}
+		identities = append(identities, identity)
+		want = append(want, identity)
+	}
+
+	got, err := MapIdentities(identities)
+	if err != nil {
+		t.Fatalf("MapIdentities() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MapIdentities() = %v..., want %v...", got[:4], want[:4])
+	}
+}
@@ -0,0 +1,141 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Modes of gpair.mentions, which decide what happens to @alias mentions in a commit message
+const (
+	// MentionsStrip removes the mentions once their trailers are added
+	MentionsStrip = "strip"
+	// MentionsKeep leaves the mentions in the message
+	MentionsKeep = "keep"
+	// MentionsOff ignores mentions altogether
+	MentionsOff = "off"
+)
+
+// mentionName matches an alias after the @ sign. A trailing dot or dash ends the sentence, not the alias.
+const mentionName = `[A-Za-z0-9_](?:[A-Za-z0-9_.-]*[A-Za-z0-9_])?`
+
+// mentionPattern matches a mention that starts a word, which an email address does not
+var mentionPattern = regexp.MustCompile(`(?:^|[\s(,])@(` + mentionName + `)`)
+
+// mentionEnds are the characters that cannot follow a mention, such as the slash of a package like @types/node
+// or the apostrophe of a possessive like @bo's
+const mentionEnds = "/'’"
+
+// mentionListPattern matches a run of mentions such as "w/ @alice and @bob", along with the space before it
+var mentionListPattern = regexp.MustCompile(`(?i)(?:^|\s+)(?:(?:w/|with|cc:?|paired with)\s+)?` +
+	`@` + mentionName + `(?:(?:\s*,\s*|\s+(?:and|&)\s+|\s+)@` + mentionName + `)*`)
+
+// GetMentionsMode returns the effective gpair.mentions, or MentionsOff if it is not set
+func GetMentionsMode() (string, error) {
+	mode, err := getConfig("gpair.mentions")
+	if err != nil || mode == "" {
+		return MentionsOff, err
+	}
+
+	return strings.ToLower(mode), nil
+}
+
+// SetMentionsMode sets gpair.mentions, in the global config if global is true and the repo's config otherwise
+func SetMentionsMode(mode string, global bool) error {
+	return setConfig(global, "gpair.mentions", mode)
+}
+
+// FindMentions returns the aliases mentioned as @alias in a commit message, in order and without duplicates.
+// Comments and everything after the scissors line are ignored.
+func FindMentions(message, commentChar string) []string {
+	var aliases []string
+	seen := make(map[string]bool)
+	for _, line := range messageLines(message, commentChar) {
+		for _, match := range mentionPattern.FindAllStringSubmatchIndex(line, -1) {
+			alias := line[match[2]:match[3]]
+			if isMention(line, match[3]) && !seen[alias] {
+				seen[alias] = true
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+
+	return aliases
+}
+
+// StripMentions removes the mentions of the given aliases from a commit message, with a "w/", "with" or "cc" before them.
+// A run of mentions is only removed if it mentions nothing but those aliases. A line left empty is removed.
+// Comments and everything after the scissors line are left alone.
+func StripMentions(message, commentChar string, aliases []string) string {
+	strip := make(map[string]bool)
+	for _, alias := range aliases {
+		strip[alias] = true
+	}
+
+	lines := strings.Split(message, "\n")
+	count := len(messageLines(message, commentChar))
+
+	var result []string
+	for i, line := range lines {
+		if i >= count || isComment(line, commentChar) || !mentionPattern.MatchString(line) {
+			result = append(result, line)
+			continue
+		}
+
+		stripped := ""
+		last := 0
+		for _, match := range mentionListPattern.FindAllStringIndex(line, -1) {
+			if !isMention(line, match[1]) || !onlyMentions(line[match[0]:match[1]], strip) {
+				continue
+			}
+
+			stripped += line[last:match[0]]
+			last = match[1]
+		}
+		stripped = strings.TrimRight(stripped+line[last:], " \t")
+		if strings.HasPrefix(line, "@") {
+			stripped = strings.TrimLeft(stripped, " \t")
+		}
+
+		if stripped != "" {
+			result = append(result, stripped)
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+// isMention returns true if a mention that ends at index end in line is a whole mention,
+// rather than the start of something else such as @types/node or @bo's
+func isMention(line string, end int) bool {
+	next, _ := utf8.DecodeRuneInString(line[end:])
+	return end >= len(line) || !strings.ContainsRune(mentionEnds, next)
+}
+
+// onlyMentions returns true if every alias mentioned in a run of mentions is one of the given aliases
+func onlyMentions(run string, aliases map[string]bool) bool {
+	for _, match := range mentionPattern.FindAllStringSubmatch(" "+run, -1) {
+		if !aliases[match[1]] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// messageLines returns the lines of a commit message before the scissors line, with comment lines blanked out
+func messageLines(message, commentChar string) []string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if line == commentChar+scissors {
+			break
+		}
+
+		if isComment(line, commentChar) {
+			line = ""
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
@@ -0,0 +1,56 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []string
+	}{
+		{"subject", "fix flaky test w/ @alice @bob\n", []string{"alice", "bob"}},
+		{"end of sentence", "Paired with @alice.\n", []string{"alice"}},
+		{"duplicates", "fix @alice\n\nthanks @alice, @bob\n", []string{"alice", "bob"}},
+		{"email", "mail al@example.com\n", nil},
+		{"package", "Bump @types/node to 20\n", nil},
+		{"possessive", "see @bo's patch\n", nil},
+		{"comment", "fix\n# @alice\n", nil},
+		{"after scissors", "fix\n#" + scissors + "\n+@alice\n @bob\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindMentions(tt.message, "#"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindMentions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripMentions(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"with", "fix flaky test w/ @alice @bob\n", "fix flaky test\n"},
+		{"list", "fix flaky test with @alice, @bob and @carol\n", "fix flaky test\n"},
+		{"start of line", "@alice fix flaky test\n", "fix flaky test\n"},
+		{"line of mentions", "fix flaky test\n\ncc @alice @bob\n", "fix flaky test\n\n"},
+		{"email kept", "fix w/ @alice\n\nmail al@example.com\n", "fix\n\nmail al@example.com\n"},
+		{"comment kept", "fix w/ @alice\n# @bob\n", "fix\n# @bob\n"},
+		{"other mentions kept", "Use @Override on toString w/ @alice\n", "Use @Override on toString\n"},
+		{"package kept", "Bump @types/node w/ @alice\n", "Bump @types/node\n"},
+		{"possessive kept", "see @bob's patch w/ @alice\n", "see @bob's patch\n"},
+		{"curly possessive kept", "see @bob’s patch\n", "see @bob’s patch\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripMentions(tt.message, "#", []string{"alice", "bob", "carol"}); got != tt.want {
+				t.Errorf("StripMentions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// enterTestRepo creates a git repo in a temporary directory and changes into it, with a home directory of its own
+// so that the user's global git config is neither read nor written. The returned function undoes all of it.
+func enterTestRepo(t *testing.T) (string, func()) {
+	t.Helper()
+
+	tempDir, err := ioutil.TempDir("", "gpair_repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The temporary directory may be behind a symlink, as on macOS, and git reports resolved paths
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	home := filepath.Join(tempDir, "home")
+	repo := filepath.Join(tempDir, "repo")
+	for _, dir := range []string{home, repo} {
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := map[string]string{"HOME": home, "XDG_CONFIG_HOME": filepath.Join(home, ".config"), "GIT_CONFIG_NOSYSTEM": "1"}
+	previous := make(map[string]*string)
+	for key, value := range env {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cleanup := func() {
+		os.Chdir(wd)
+		for key, old := range previous {
+			if old == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *old)
+			}
+		}
+		os.RemoveAll(tempDir)
+	}
+
+	if out, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+		cleanup()
+		t.Fatalf("git init failed: %v %s", err, out)
+	}
+
+	if err := os.Chdir(repo); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	return repo, cleanup
+}
+
+// runGit runs git in the current directory, failing the test if it fails
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v %s", args, err, out)
+	}
+
+	return string(out)
+}
@@ -0,0 +1,222 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+// identPattern matches the author or committer line of a raw commit: name, email and date in git's internal format
+var identPattern = regexp.MustCompile(`^(.*) <(.*)> (\d+ [+-]\d{4})$`)
+
+// NoComments is a comment character that never starts a line, for parsing committed messages, which have no comments
+const NoComments = "\x00"
+
+// RawCommit is a commit as git stores it, with everything needed to write a copy of it
+type RawCommit struct {
+	SHA     string
+	Tree    string
+	Parents []string
+	// Author and Committer are in git's internal format: "Name <email> 1600000000 +0200"
+	Author    string
+	Committer string
+	Encoding  string
+	// Signed is true if the commit has a GPG or SSH signature, which a copy cannot keep
+	Signed  bool
+	Message string
+}
+
+// ReadCommit returns the commit with the given SHA as git stores it
+func ReadCommit(sha string) (RawCommit, error) {
+	out, err := exec.Command("git", "cat-file", "commit", sha).Output()
+	if err != nil {
+		return RawCommit{}, fmt.Errorf("failed to read commit %s: %v", sha, err)
+	}
+
+	raw := RawCommit{SHA: sha}
+	headers := string(out)
+	if i := strings.Index(headers, "\n\n"); i >= 0 {
+		headers, raw.Message = headers[:i], headers[i+2:]
+	}
+
+	for _, line := range strings.Split(headers, "\n") {
+		key, value := line, ""
+		if i := strings.Index(line, " "); i >= 0 {
+			key, value = line[:i], line[i+1:]
+		}
+
+		switch key {
+		case "tree":
+			raw.Tree = value
+		case "parent":
+			raw.Parents = append(raw.Parents, value)
+		case "author":
+			raw.Author = value
+		case "committer":
+			raw.Committer = value
+		case "encoding":
+			raw.Encoding = value
+		case "gpgsig", "gpgsig-sha256":
+			raw.Signed = true
+		}
+	}
+
+	return raw, nil
+}
+
+// GetAuthor returns the name and email of the author of the commit
+func (c RawCommit) GetAuthor() config.Collaborator {
+	return parseIdent(c.Author)
+}
+
+// GetCommitter returns the name and email of the committer of the commit
+func (c RawCommit) GetCommitter() config.Collaborator {
+	return parseIdent(c.Committer)
+}
+
+// parseIdent returns the name and email in an author or committer line
+func parseIdent(ident string) config.Collaborator {
+	match := identPattern.FindStringSubmatch(ident)
+	if match == nil {
+		return config.Collaborator{}
+	}
+
+	return config.Collaborator{Name: match[1], Email: match[2]}
+}
+
+// Subject returns the first line of the commit message
+func (c RawCommit) Subject() string {
+	return strings.SplitN(c.Message, "\n", 2)[0]
+}
+
+// Write writes a copy of the commit with other parents and message, keeping its tree, authorship and dates,
+// and returns the SHA of the copy
+func (c RawCommit) Write(parents []string, message string) (string, error) {
+	var env []string
+	for prefix, ident := range map[string]string{"GIT_AUTHOR": c.Author, "GIT_COMMITTER": c.Committer} {
+		match := identPattern.FindStringSubmatch(ident)
+		if match == nil {
+			return "", fmt.Errorf("failed to parse '%s' in commit %s", ident, c.SHA)
+		}
+
+		env = append(env, prefix+"_NAME="+match[1], prefix+"_EMAIL="+match[2], prefix+"_DATE="+match[3])
+	}
+
+	var args []string
+	if c.Encoding != "" {
+		args = append(args, "-c", "i18n.commitEncoding="+c.Encoding)
+	}
+	args = append(args, "commit-tree", c.Tree)
+	for _, parent := range parents {
+		args = append(args, "-p", parent)
+	}
+	args = append(args, "-F", "-")
+
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = strings.NewReader(message)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite commit %s: %s", c.SHA, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// AppendMessageTrailers adds trailers to the end of a committed message, skipping any that are already present.
+// The sign-off that ends the trailers is moved after the others, as in MoveSignOffLast.
+func AppendMessageTrailers(message string, trailers []string) string {
+	message = MoveSignOffLast(message, trailers, NoComments)
+
+	return AppendTrailers(message, trailers, NoComments)
+}
+
+// RevList returns the commits git rev-list lists with the given arguments, or an ErrRevisionRange
+func RevList(args ...string) ([]string, error) {
+	cmd := exec.Command("git", append([]string{"rev-list"}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return nil, &ErrRevisionRange{strings.Join(args, " "), strings.TrimSpace(stderr.String())}
+		}
+
+		return nil, err
+	}
+
+	var shas []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			shas = append(shas, line)
+		}
+	}
+
+	return shas, nil
+}
+
+// IsPushed returns true if the commit is on the upstream of the current branch,
+// or on any remote-tracking branch if the current branch has no upstream
+func IsPushed(sha string) (bool, error) {
+	err := exec.Command("git", "rev-parse", "--verify", "--quiet", "@{upstream}").Run()
+	if err == nil {
+		err = exec.Command("git", "merge-base", "--is-ancestor", sha, "@{upstream}").Run()
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+
+		return err == nil, err
+	}
+
+	out, err := exec.Command("git", "for-each-ref", "--contains", sha, "--format=%(refname)", "refs/remotes").Output()
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// GetOperationInProgress returns the name of the rebase, merge, cherry-pick or revert in progress, if any
+func GetOperationInProgress() (string, error) {
+	operations := []struct{ path, name string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+	}
+
+	for _, operation := range operations {
+		path, err := GetGitPath(operation.path)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := os.Stat(path); err == nil {
+			return operation.name, nil
+		}
+	}
+
+	return "", nil
+}
+
+// UpdateHead points HEAD, or the branch it is on, at newSHA if it still points at oldSHA
+func UpdateHead(newSHA, oldSHA, reason string) error {
+	cmd := exec.Command("git", "update-ref", "-m", reason, "HEAD", newSHA, oldSHA)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to update HEAD: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+func TestRawCommitIdents(t *testing.T) {
+	commit := RawCommit{Author: "Alice Smith <alice@example.com> 1600000000 +0200", Committer: "broken"}
+
+	if got, want := commit.GetAuthor(), (config.Collaborator{Name: "Alice Smith", Email: "alice@example.com"}); got != want {
+		t.Errorf("GetAuthor() = %v, want %v", got, want)
+	}
+
+	if got := commit.GetCommitter(); got != (config.Collaborator{}) {
+		t.Errorf("GetCommitter() of a malformed ident = %v, want an empty collaborator", got)
+	}
+}
+
+func TestAppendMessageTrailers(t *testing.T) {
+	coauthor := "Co-authored-by: Alice <alice@example.com>"
+	tests := []struct {
+		name     string
+		message  string
+		trailers []string
+		want     string
+	}{
+		{"subject only", "fix bug\n", []string{coauthor}, "fix bug\n\n" + coauthor + "\n"},
+		{"hash lines are not comments", "fix bug\n\n#123 is fixed\n", []string{coauthor}, "fix bug\n\n#123 is fixed\n\n" + coauthor + "\n"},
+		{"already present", "fix bug\n\n" + coauthor + "\n", []string{coauthor}, "fix bug\n\n" + coauthor + "\n"},
+		{
+			"sign-off moved last",
+			"fix bug\n\nSigned-off-by: Me <me@example.com>\n",
+			GetTrailers(DCOStyle, me, alice),
+			"fix bug\n\nCo-developed-by: Alice <alice@example.com>\nSigned-off-by: Alice <alice@example.com>\nSigned-off-by: Me <me@example.com>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AppendMessageTrailers(tt.message, tt.trailers); got != tt.want {
+				t.Errorf("AppendMessageTrailers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
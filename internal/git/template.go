@@ -1,21 +1,113 @@
 package git
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
 	"github.com/adavidalbertson/gpair/internal/config"
 	"github.com/adavidalbertson/gpair/internal/store"
 )
 
-// CreateTemplate saves a git commit template containing the paired collaborators
-func CreateTemplate(repoName string, coauthors ...config.Collaborator) (string, error) {
-	store, err := store.NewFileStore(repoName + "-template.txt", store.HOME, ".gpair")
+// templateMarker separates the co-author block from the rest of a gpair template, after the comment character
+const templateMarker = "Co-author trailer provided by gpair"
+
+// defaultTemplateFormat renders the user's own template, or an empty subject line and a blank line,
+// followed by the trailers of the coauthors
+const defaultTemplateFormat = `{{if .Base}}{{.Base}}{{else}}
+
+{{end}}{{comment "` + templateMarker + `"}}{{range .Trailers}}{{.}}
+{{end}}`
+
+// TemplateData is what a commit template is rendered from, including a custom one set with gpair.templateFile
+type TemplateData struct {
+	Coauthors []config.Collaborator
+	// Trailers are the lines crediting the coauthors in the trailer style of the pairing
+	Trailers []string
+	// Style is the trailer style of the pairing, DefaultStyle or DCOStyle
+	Style string
+	// Repo and Branch are empty for a pairing that is not for a single repo
+	Repo   string
+	Branch string
+	Start  time.Time
+	// Self is the user, from user.name and user.email
+	Self config.Collaborator
+	// Base is the content of the user's own commit template followed by a blank line, or empty if there is none
+	Base string
+	// CommentChar is the comment character git strips, or empty if the comments would end up in the commit message
+	CommentChar string
+}
+
+// ErrTemplateFormat is returned when the custom template set with gpair.templateFile cannot be rendered
+type ErrTemplateFormat struct {
+	Path string
+	Err  error
+}
+
+func (err *ErrTemplateFormat) Error() string {
+	return fmt.Sprintf("failed to render the template %s set in gpair.templateFile: %v", err.Path, err.Err)
+}
+
+// NewTemplateData returns the data to render a template for a pairing with coauthors since start, in the given trailer style.
+// The repo and branch are only filled in if the pairing is for the current repo, and not shared by several.
+// The comment character is left out if git would keep comments, according to commit.cleanup and core.commentChar.
+func NewTemplateData(shared bool, style string, start time.Time, coauthors ...config.Collaborator) (TemplateData, error) {
+	data := TemplateData{Coauthors: coauthors, Style: style, Start: start}
+
+	var err error
+	if !shared {
+		data.Repo, err = GetRepoName()
+		if err != nil {
+			return data, err
+		}
+
+		data.Branch, err = GetCurrentBranch()
+		if err != nil {
+			return data, err
+		}
+	}
+
+	data.Self, err = GetUser()
 	if err != nil {
-		return "", err
+		return data, err
+	}
+	data.Trailers = GetTrailers(style, data.Self, coauthors...)
+
+	cleanup, err := GetCleanupMode()
+	if err != nil {
+		return data, err
 	}
 
-	template := "\n\n# Co-author trailer provided by gpair\n\n"
+	commentChar, err := getCommentSetting()
+	if err != nil {
+		return data, err
+	}
 
-	for _, coauthor := range coauthors {
-		template += coauthor.String() + "\n"
+	// With "auto", git would pick a different comment character for a template containing gpair's comment
+	if StripsComments(cleanup) && commentChar != "auto" {
+		data.CommentChar = commentChar
+	}
+
+	return data, nil
+}
+
+// CreateTemplate saves a git commit template rendered from data, containing the paired collaborators.
+// If data has a base, the co-author block is added after it, so that gpair can compose with a user's own template.
+func CreateTemplate(key string, data TemplateData) (string, error) {
+	store, err := store.NewFileStore(key + "-template.txt", store.HOME, ".gpair")
+	if err != nil {
+		return "", err
+	}
+
+	template, err := RenderTemplate(data)
+	if err != nil {
+		return "", err
 	}
 
 	err = store.Write([]byte(template))
@@ -24,4 +116,173 @@ func CreateTemplate(repoName string, coauthors ...config.Collaborator) (string,
 	}
 
 	return store.GetPath(), nil
-}
\ No newline at end of file
+}
+
+// RenderTemplate renders a commit template from data, with the custom template set with gpair.templateFile if any.
+// Besides the fields of TemplateData, the template can use {{comment "text"}}, which renders a comment line
+// only if git strips comments, and {{join .Coauthors "sep"}}.
+func RenderTemplate(data TemplateData) (string, error) {
+	format := defaultTemplateFormat
+
+	formatPath, err := GetTemplateFile()
+	if err != nil {
+		return "", err
+	}
+
+	if formatPath != "" {
+		format, err = ReadTemplate(formatPath)
+		if err != nil {
+			return "", &ErrTemplateFormat{formatPath, err}
+		}
+	}
+
+	rendered, err := renderTemplate(format, data)
+	if err != nil {
+		return "", &ErrTemplateFormat{formatPath, err}
+	}
+
+	return rendered, nil
+}
+
+// renderTemplate renders a commit template from data with the given text/template format
+func renderTemplate(format string, data TemplateData) (string, error) {
+	if strings.TrimSpace(data.Base) != "" {
+		data.Base = strings.TrimRight(data.Base, "\n") + "\n\n"
+	} else {
+		data.Base = ""
+	}
+
+	funcs := template.FuncMap{
+		"comment": func(text string) string {
+			if data.CommentChar == "" {
+				return ""
+			}
+
+			return data.CommentChar + " " + text + "\n"
+		},
+		"join": func(coauthors []config.Collaborator, sep string) string {
+			var lines []string
+			for _, coauthor := range coauthors {
+				lines = append(lines, coauthor.String())
+			}
+
+			return strings.Join(lines, sep)
+		},
+	}
+
+	tmpl, err := template.New("gpair").Funcs(funcs).Parse(format)
+	if err != nil {
+		return "", err
+	}
+
+	var rendered strings.Builder
+	err = tmpl.Execute(&rendered, data)
+
+	return rendered.String(), err
+}
+
+// CreateComposedTemplate saves a git commit template containing the paired collaborators after the content of
+// the commit template the user had set before pairing, if any. That template is remembered so it can be restored.
+func CreateComposedTemplate(key string, global bool, data TemplateData) (string, error) {
+	currentTemplate, err := GetTemplate(global)
+	if err != nil {
+		return "", err
+	}
+
+	if currentTemplate != "" && !IsGpairPath(currentTemplate) {
+		err = SetOriginalTemplate(currentTemplate, global)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	data.Base, err = GetBaseTemplate(global)
+	if err != nil {
+		return "", err
+	}
+
+	return CreateTemplate(key, data)
+}
+
+// GetBaseTemplate returns the content of the user's own commit template that a gpair template is composed with:
+// the one currently set if it is not gpair's, the one gpair replaced, or else the one a per-repo template would hide.
+func GetBaseTemplate(global bool) (string, error) {
+	currentTemplate, err := GetTemplate(global)
+	if err != nil {
+		return "", err
+	}
+
+	originalTemplate, err := GetOriginalTemplate(global)
+	if err != nil {
+		return "", err
+	}
+
+	if currentTemplate != "" && !IsGpairPath(currentTemplate) {
+		originalTemplate = currentTemplate
+	}
+
+	if originalTemplate == "" && !global {
+		// A local template would hide the user's global template, so include it without replacing it
+		originalTemplate, err = getInheritedTemplate()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if originalTemplate == "" {
+		return "", nil
+	}
+
+	return ReadTemplate(originalTemplate)
+}
+
+// GetTemplateFile returns the effective gpair.templateFile, the path of a custom text/template for commit templates
+func GetTemplateFile() (string, error) {
+	return getConfig("gpair.templateFile")
+}
+
+// ReadTemplate returns the content of the commit template at templatePath, expanding the path as git would
+func ReadTemplate(templatePath string) (string, error) {
+	if strings.HasPrefix(templatePath, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.Wrap(err, "failed to locate user home directory")
+		}
+
+		templatePath = filepath.Join(home, templatePath[2:])
+	} else if !filepath.IsAbs(templatePath) {
+		// git resolves a relative template path from the top level of the repo
+		if repoRoot, err := GetRepoRoot(); err == nil {
+			templatePath = filepath.Join(repoRoot, templatePath)
+		}
+	}
+
+	templateBytes, err := ioutil.ReadFile(templatePath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read commit template %s", templatePath)
+	}
+
+	return string(templateBytes), nil
+}
+
+// getInheritedTemplate returns the commit.template that a per-repo template hides, which is the one in the repo's
+// shared config when each worktree has its own pairing, or else the global one. A gpair template is not returned.
+func getInheritedTemplate() (string, error) {
+	scopes := []string{"--global"}
+	if IsWorktreeScope() {
+		scopes = append([]string{"--local"}, scopes...)
+	}
+
+	for _, scope := range scopes {
+		template, err := getConfig(scope, "commit.template")
+		if err != nil {
+			return "", err
+		}
+
+		if template != "" && !IsGpairPath(template) {
+			return template, nil
+		}
+	}
+
+	return "", nil
+}
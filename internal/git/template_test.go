@@ -0,0 +1,187 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+func Test_renderTemplate(t *testing.T) {
+	alice := config.NewCollaborator("alice", "Alice", "alice@example.com")
+	bob := config.NewCollaborator("bob", "Bob", "bob@example.com")
+	trailer := alice.String() + "\n"
+
+	tests := []struct {
+		name        string
+		format      string
+		base        string
+		commentChar string
+		want        string
+	}{
+		{"default", defaultTemplateFormat, "", "#", "\n\n# " + templateMarker + "\n" + trailer},
+		{"other comment char", defaultTemplateFormat, "", ";", "\n\n; " + templateMarker + "\n" + trailer},
+		{"comments kept", defaultTemplateFormat, "", "", "\n\n" + trailer},
+		{"composed", defaultTemplateFormat, "Ticket: \n\n\n", "#", "Ticket: \n\n# " + templateMarker + "\n" + trailer},
+		{"composed comments kept", defaultTemplateFormat, "Ticket: \n", "", "Ticket: \n\n" + trailer},
+		{"custom", "[{{.Repo}}] \n\n{{comment \"Ticket\"}}Ticket: \n\n{{join .Coauthors \"\\n\"}}\n", "", "#", "[api] \n\n# Ticket\nTicket: \n\n" + trailer},
+		{"custom comments kept", "{{comment \"Ticket\"}}\n\n{{range .Coauthors}}{{.}}\n{{end}}", "", "", "\n\n" + trailer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := TemplateData{Coauthors: []config.Collaborator{alice}, Trailers: []string{alice.String()}, Repo: "api", Base: tt.base, CommentChar: tt.commentChar}
+			got, err := renderTemplate(tt.format, data)
+			if err != nil {
+				t.Fatalf("renderTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderTemplate() = %q, want %q", got, tt.want)
+			}
+
+			// gpair must find the trailers again in every rendering
+			if trailers := ExtractTrailers(got); len(trailers) != 1 || trailers[0] != alice.String() {
+				t.Errorf("ExtractTrailers(renderTemplate()) = %v, want [%s]", trailers, alice.String())
+			}
+		})
+	}
+
+	t.Run("order", func(t *testing.T) {
+		data := TemplateData{Coauthors: []config.Collaborator{alice, bob}}
+		got, err := renderTemplate("{{range $i, $c := .Coauthors}}{{if $i}}{{$c}}\n{{end}}{{end}}{{index .Coauthors 0}}\n", data)
+		if err != nil {
+			t.Fatalf("renderTemplate() error = %v", err)
+		}
+		if want := bob.String() + "\n" + alice.String() + "\n"; got != want {
+			t.Errorf("renderTemplate() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := renderTemplate("{{.Missing}}", TemplateData{}); err == nil {
+			t.Errorf("renderTemplate() error = nil, want an error for an unknown field")
+		}
+	})
+}
+
+func Test_autoCommentChar(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"unused", "fix bug\n", "#"},
+		{"hash used", "#123 fixed\n", ";"},
+		{"hash and semicolon used", "; not a comment\n#123 fixed\n", "@"},
+		{"git comments present", "fix bug\n\n; Please enter the commit message\n;\n", ";"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := autoCommentChar(tt.message); got != tt.want {
+				t.Errorf("autoCommentChar() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposedTemplate(t *testing.T) {
+	alice := config.NewCollaborator("alice", "Alice", "alice@example.com")
+	own := "Subject\n\nWhy:\n"
+
+	tests := []struct {
+		name   string
+		global bool
+		// ownScope is where the user's own template is set: "local", "global" or "" for nowhere
+		ownScope     string
+		wantBase     string
+		wantOriginal string
+	}{
+		{"local pairing with a local template", false, "local", own, "~/own-template.txt"},
+		{"local pairing with a global template", false, "global", own, ""},
+		{"local pairing without a template", false, "", "", ""},
+		{"global pairing with a global template", true, "global", own, "~/own-template.txt"},
+		{"global pairing without a template", true, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := enterTestRepo(t)
+			defer cleanup()
+
+			home, err := os.UserHomeDir()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ioutil.WriteFile(filepath.Join(home, "own-template.txt"), []byte(own), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The path is restored as the user wrote it, without expanding it
+			if tt.ownScope != "" {
+				runGit(t, "config", "--"+tt.ownScope, "commit.template", "~/own-template.txt")
+			}
+
+			// Pairing twice must not forget the user's own template
+			for i := 0; i < 2; i++ {
+				data, err := NewTemplateData(tt.global, DefaultStyle, time.Now(), alice)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				templatePath, err := CreateComposedTemplate("gpair-test", tt.global, data)
+				if err != nil {
+					t.Fatalf("CreateComposedTemplate() error = %v", err)
+				}
+
+				err = SetTemplate(templatePath, tt.global)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				template, err := ReadTemplate(templatePath)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !strings.HasPrefix(template, tt.wantBase) || !strings.Contains(template, "Co-authored-by: Alice <alice@example.com>\n") {
+					t.Errorf("CreateComposedTemplate() wrote %q, want the co-author after %q", template, tt.wantBase)
+				}
+			}
+
+			base, err := GetBaseTemplate(tt.global)
+			if err != nil || base != tt.wantBase {
+				t.Errorf("GetBaseTemplate() = %q, %v, want %q", base, err, tt.wantBase)
+			}
+
+			original, err := GetOriginalTemplate(tt.global)
+			if err != nil || original != tt.wantOriginal {
+				t.Errorf("GetOriginalTemplate() = %q, %v, want %q", original, err, tt.wantOriginal)
+			}
+
+			err = RestoreTemplate(tt.global)
+			if err != nil {
+				t.Fatalf("RestoreTemplate() error = %v", err)
+			}
+
+			for _, scope := range []string{"local", "global"} {
+				want := ""
+				if scope == tt.ownScope {
+					want = "~/own-template.txt"
+				}
+
+				got, err := getConfig("--"+scope, "commit.template")
+				if err != nil || got != want {
+					t.Errorf("commit.template in the %s config is %q, %v after RestoreTemplate(), want %q", scope, got, err, want)
+				}
+			}
+
+			if original, _ := GetOriginalTemplate(tt.global); original != "" {
+				t.Errorf("RestoreTemplate() kept gpair.originalTemplate = %q", original)
+			}
+		})
+	}
+}
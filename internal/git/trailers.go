@@ -0,0 +1,210 @@
+package git
+
+import (
+	"regexp"
+	"strings"
+)
+
+// scissors is the line git uses to mark the start of the diff in verbose commit messages
+const scissors = " ------------------------ >8 ------------------------"
+
+var trailerPattern = regexp.MustCompile(`^([A-Za-z0-9-]+)\s*:\s*(.*\S)\s*$`)
+
+// IsTrailer returns true if the line has the form of a git trailer, e.g. "Co-authored-by: Name <email>"
+func IsTrailer(line string) bool {
+	return trailerPattern.MatchString(line)
+}
+
+// ExtractTrailers returns the trailer lines found in a gpair template or pairing file.
+// Only the co-author block is considered if the template was composed with a user's own template,
+// which is after the gpair comment if there is one, or else the last paragraph.
+func ExtractTrailers(content string) []string {
+	if i := strings.Index(content, templateMarker); i >= 0 {
+		content = content[i+len(templateMarker):]
+	} else if i := strings.LastIndex(strings.TrimRight(content, "\n"), "\n\n"); i >= 0 {
+		content = content[i:]
+	}
+
+	var trailers []string
+	for _, line := range strings.Split(content, "\n") {
+		if IsTrailer(line) {
+			trailers = append(trailers, strings.TrimSpace(line))
+		}
+	}
+
+	return trailers
+}
+
+// AppendTrailers adds trailers to the end of a commit message, skipping any that are already present.
+// The trailers are inserted above git's trailing comment block and scissors line, if any,
+// whose lines start with commentChar.
+func AppendTrailers(message string, trailers []string, commentChar string) string {
+	tail := ""
+	if i := strings.Index(message, "\n"+commentChar+scissors); i >= 0 {
+		message, tail = message[:i+1], message[i+1:]
+	} else if strings.HasPrefix(message, commentChar+scissors) {
+		message, tail = "", message
+	}
+
+	var lines []string
+	if message != "" {
+		lines = strings.Split(strings.TrimSuffix(message, "\n"), "\n")
+	}
+
+	// Find the end of the message content, before any trailing blank lines and comments
+	end := len(lines)
+	for end > 0 && (isBlank(lines[end-1]) || isComment(lines[end-1], commentChar)) {
+		end--
+	}
+	content := append([]string{}, lines[:end]...)
+	comments := lines[end:]
+
+	var missing []string
+	for _, trailer := range trailers {
+		if !containsTrailer(content, trailer) && !containsTrailer(missing, trailer) {
+			missing = append(missing, trailer)
+		}
+	}
+
+	if len(missing) == 0 {
+		return message + tail
+	}
+
+	if len(content) == 0 {
+		// Leave room for the subject and body above the trailers, as the template does
+		content = []string{"", ""}
+	} else if !endsWithTrailerBlock(content, commentChar) {
+		content = append(content, "")
+	}
+	content = append(content, missing...)
+
+	var result []string
+	result = append(result, content...)
+	result = append(result, comments...)
+
+	return strings.Join(result, "\n") + "\n" + tail
+}
+
+// endsWithTrailerBlock returns true if the last paragraph of the message consists only of trailers.
+// A single paragraph is never a trailer block, since it is the subject of the commit.
+func endsWithTrailerBlock(lines []string, commentChar string) bool {
+	start := len(lines)
+	for start > 0 && !isBlank(lines[start-1]) {
+		start--
+	}
+
+	if start == 0 {
+		return false
+	}
+
+	for _, line := range lines[start:] {
+		if !IsTrailer(line) && !isComment(line, commentChar) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsTrailer(lines []string, trailer string) bool {
+	for _, line := range lines {
+		if trailersEqual(line, trailer) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trailersEqual compares two trailers, ignoring the case of the key and surrounding whitespace
+func trailersEqual(a, b string) bool {
+	aMatch := trailerPattern.FindStringSubmatch(strings.TrimSpace(a))
+	bMatch := trailerPattern.FindStringSubmatch(strings.TrimSpace(b))
+	if aMatch == nil || bMatch == nil {
+		return false
+	}
+
+	return strings.EqualFold(aMatch[1], bMatch[1]) && aMatch[2] == bMatch[2]
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+func isComment(line, commentChar string) bool {
+	return strings.HasPrefix(line, commentChar)
+}
+
+// Trailer is a trailer parsed from a commit message, with any folded continuation lines joined into its value
+type Trailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (t Trailer) String() string {
+	return t.Key + ": " + t.Value
+}
+
+// parsedTrailerPattern matches a trailer whose value may be folded onto the lines after it, and so may start empty
+var parsedTrailerPattern = regexp.MustCompile(`^([A-Za-z0-9-]+)\s*:\s*(.*?)\s*$`)
+
+// dividerPattern matches the line that separates a commit message from the notes or patch after it
+var dividerPattern = regexp.MustCompile(`^---(\s|$)`)
+
+// ParseTrailers returns the trailers in the trailer block of a commit message, like git interpret-trailers.
+// Comment lines are ignored, and so is everything after a "---" divider or the scissors line.
+// The trailer block is the last paragraph, unless it is the subject. A line starting with whitespace continues
+// the trailer before it. The block may hold other lines only if a quarter of them are trailers, one of them a sign-off.
+func ParseTrailers(message, commentChar string) []Trailer {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if (commentChar != "" && line == commentChar+scissors) || dividerPattern.MatchString(line) {
+			break
+		}
+
+		if commentChar != "" && isComment(line, commentChar) {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+
+	end := len(lines)
+	for end > 0 && isBlank(lines[end-1]) {
+		end--
+	}
+
+	start := end
+	for start > 0 && !isBlank(lines[start-1]) {
+		start--
+	}
+
+	if start == 0 {
+		return nil
+	}
+
+	var trailers []Trailer
+	others := 0
+	signedOff := false
+	for _, line := range lines[start:end] {
+		if (line[0] == ' ' || line[0] == '\t') && len(trailers) > 0 {
+			last := &trailers[len(trailers)-1]
+			last.Value = strings.TrimSpace(last.Value + " " + strings.TrimSpace(line))
+			continue
+		}
+
+		match := parsedTrailerPattern.FindStringSubmatch(line)
+		if match == nil {
+			others++
+			continue
+		}
+
+		trailers = append(trailers, Trailer{Key: match[1], Value: match[2]})
+		signedOff = signedOff || strings.EqualFold(match[1], SignedOffBy)
+	}
+
+	if others > 0 && (!signedOff || len(trailers)*3 < others) {
+		return nil
+	}
+
+	return trailers
+}
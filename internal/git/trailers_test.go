@@ -0,0 +1,111 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAppendTrailers(t *testing.T) {
+	alice := "Co-authored-by: Alice <alice@example.com>"
+	bob := "Co-authored-by: Bob <bob@example.com>"
+
+	tests := []struct {
+		name        string
+		message     string
+		trailers    []string
+		want        string
+		commentChar string
+	}{
+		{"subject only", "fix bug\n", []string{alice}, "fix bug\n\n" + alice + "\n", ""},
+		{"subject and body", "fix bug\n\nmore detail\n", []string{alice, bob}, "fix bug\n\nmore detail\n\n" + alice + "\n" + bob + "\n", ""},
+		{"no trailing newline", "fix bug", []string{alice}, "fix bug\n\n" + alice + "\n", ""},
+		{"empty message", "", []string{alice}, "\n\n" + alice + "\n", ""},
+		{"empty message with comments", "\n# Please enter the commit message\n", []string{alice}, "\n\n" + alice + "\n\n# Please enter the commit message\n", ""},
+		{"before comments", "fix bug\n\n# Please enter the commit message\n#\n", []string{alice}, "fix bug\n\n" + alice + "\n\n# Please enter the commit message\n#\n", ""},
+		{"existing trailer block", "fix bug\n\nSigned-off-by: Me <me@example.com>\n", []string{alice}, "fix bug\n\nSigned-off-by: Me <me@example.com>\n" + alice + "\n", ""},
+		{"already present", "fix bug\n\n" + alice + "\n", []string{alice, bob}, "fix bug\n\n" + alice + "\n" + bob + "\n", ""},
+		{"already present different case", "fix bug\n\nco-authored-by: Alice <alice@example.com>\n", []string{alice}, "fix bug\n\nco-authored-by: Alice <alice@example.com>\n", ""},
+		{"duplicate trailers", "fix bug\n", []string{alice, alice}, "fix bug\n\n" + alice + "\n", ""},
+		{"no trailers", "fix bug\n", nil, "fix bug\n", ""},
+		{"subject looks like trailer", "docs: fix typo\n", []string{alice}, "docs: fix typo\n\n" + alice + "\n", ""},
+		{
+			"scissors",
+			"fix bug\n\n# Please enter the commit message\n#" + scissors + "\ndiff --git a/a b/a\n+Co-authored-by: x\n",
+			[]string{alice},
+			"fix bug\n\n" + alice + "\n\n# Please enter the commit message\n#" + scissors + "\ndiff --git a/a b/a\n+Co-authored-by: x\n",
+			"",
+		},
+		{"other comment char", "fix bug\n\n; Please enter the commit message\n", []string{alice}, "fix bug\n\n" + alice + "\n\n; Please enter the commit message\n", ";"},
+		{"hash is not a comment", "fix bug\n\n#123 is fixed\n", []string{alice}, "fix bug\n\n#123 is fixed\n\n" + alice + "\n", ";"},
+		{
+			"other comment char scissors",
+			"fix bug\n;" + scissors + "\ndiff --git a/a b/a\n",
+			[]string{alice},
+			"fix bug\n\n" + alice + "\n;" + scissors + "\ndiff --git a/a b/a\n",
+			";",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commentChar := tt.commentChar
+			if commentChar == "" {
+				commentChar = "#"
+			}
+
+			if got := AppendTrailers(tt.message, tt.trailers, commentChar); got != tt.want {
+				t.Errorf("AppendTrailers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractTrailers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"template", "\n\n# Co-author trailer provided by gpair\n\nCo-authored-by: Alice <alice@example.com>\n", []string{"Co-authored-by: Alice <alice@example.com>"}},
+		{"composed template", "Summary: \nTicket: ABC-123\n\n# Co-author trailer provided by gpair\n\nCo-authored-by: Alice <alice@example.com>\n", []string{"Co-authored-by: Alice <alice@example.com>"}},
+		{"composed template without comment", "Summary: \nTicket: ABC-123\n\nCo-authored-by: Alice <alice@example.com>\n", []string{"Co-authored-by: Alice <alice@example.com>"}},
+		{"other comment char", "\n\n; Co-author trailer provided by gpair\nCo-authored-by: Alice <alice@example.com>\n", []string{"Co-authored-by: Alice <alice@example.com>"}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractTrailers(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExtractTrailers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrailers(t *testing.T) {
+	alice := Trailer{"Co-authored-by", "Alice <alice@example.com>"}
+	signOff := Trailer{"Signed-off-by", "Me <me@example.com>"}
+
+	tests := []struct {
+		name    string
+		message string
+		want    []Trailer
+	}{
+		{"trailer block", "fix bug\n\nmore detail\n\nCo-authored-by: Alice <alice@example.com>\n", []Trailer{alice}},
+		{"subject only", "Co-authored-by: Alice <alice@example.com>\n", nil},
+		{"not the last paragraph", "fix bug\n\nCo-authored-by: Alice <alice@example.com>\n\nmore detail\n", nil},
+		{"folded", "fix bug\n\nCo-authored-by: Alice\n  <alice@example.com>\n", []Trailer{alice}},
+		{"folded empty first line", "fix bug\n\nCo-authored-by:\n Alice <alice@example.com>\n", []Trailer{alice}},
+		{"spaces around separator", "fix bug\n\nCo-authored-by :  Alice <alice@example.com>  \n", []Trailer{alice}},
+		{"comments", "fix bug\n\nCo-authored-by: Alice <alice@example.com>\n# Co-authored-by: Bob <bob@example.com>\n", []Trailer{alice}},
+		{"divider", "fix bug\n\nCo-authored-by: Alice <alice@example.com>\n---\n\nnotes: not a trailer\n", []Trailer{alice}},
+		{"scissors", "fix bug\n\nCo-authored-by: Alice <alice@example.com>\n#" + scissors + "\n\nx: y\n", []Trailer{alice}},
+		{"mixed without sign-off", "fix bug\n\nsee the issue\nCo-authored-by: Alice <alice@example.com>\n", nil},
+		{"mixed with sign-off", "fix bug\n\n[fixed typo]\nCo-authored-by: Alice <alice@example.com>\nSigned-off-by: Me <me@example.com>\n", []Trailer{alice, signOff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTrailers(tt.message, "#"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTrailers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
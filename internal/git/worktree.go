@@ -0,0 +1,146 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// localScope is the git config option used for the per-repo pairing.
+// It is "--worktree" when each worktree of the repo has its own pairing, see UseWorktreeConfig.
+var localScope = "--local"
+
+// UseWorktreeConfig makes gpair read and write the per-repo pairing with git config --worktree,
+// so that each worktree of the repo has its own pairing
+func UseWorktreeConfig() {
+	localScope = "--worktree"
+}
+
+// IsWorktreeScope returns true if the per-repo pairing is kept in the config of the current worktree
+func IsWorktreeScope() bool {
+	return localScope == "--worktree"
+}
+
+// IsWorktreeConfigEnabled returns true if extensions.worktreeConfig is set in the current repo,
+// which lets each worktree have config of its own
+func IsWorktreeConfigEnabled() (bool, error) {
+	enabled, err := getConfig("--bool", "extensions.worktreeConfig")
+	return enabled == "true", err
+}
+
+// EnableWorktreeConfig sets extensions.worktreeConfig in the current repo.
+// It refuses if core.bare or core.worktree are set in the shared config, which git expects to be moved
+// to the config of the main worktree first.
+func EnableWorktreeConfig() error {
+	for _, key := range []string{"core.bare", "core.worktree"} {
+		value, err := getConfig("--local", key)
+		if err != nil {
+			return err
+		}
+
+		if value != "" && !(key == "core.bare" && value == "false") {
+			return fmt.Errorf("%s is set in the shared config of this repo, move it to the main worktree's config.worktree "+
+				"before enabling extensions.worktreeConfig", key)
+		}
+	}
+
+	cmd := exec.Command("git", "config", "--local", "extensions.worktreeConfig", "true")
+	return cmd.Run()
+}
+
+// MovePairingToWorktreeConfig moves a gpair pairing from the shared config of the current repo to the config of
+// its main worktree, since every other worktree would still fall back to it. A commit.template not set by gpair stays shared.
+func MovePairingToWorktreeConfig() error {
+	keys := []string{"gpair.pairing", "gpair.originalTemplate"}
+
+	template, err := getConfig("--local", "commit.template")
+	if err != nil {
+		return err
+	}
+
+	if IsGpairPath(template) {
+		keys = append(keys, "commit.template")
+	}
+
+	return moveToMainWorktreeConfig(keys...)
+}
+
+// moveToMainWorktreeConfig moves the given keys from the shared config of the current repo to the config of its main worktree
+func moveToMainWorktreeConfig(keys ...string) error {
+	cmd := exec.Command("git", "rev-parse", "--git-common-dir")
+	commonDirBytes, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+
+	// git keeps the config of the main worktree next to the shared config
+	worktreeConfig, err := filepath.Abs(filepath.Join(strings.TrimSpace(string(commonDirBytes)), "config.worktree"))
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		value, err := getConfig("--local", key)
+		if err != nil || value == "" {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		cmd := exec.Command("git", "config", "--file", worktreeConfig, key, value)
+		err = cmd.Run()
+		if err != nil {
+			return err
+		}
+
+		cmd = exec.Command("git", "config", "--local", "--unset", key)
+		err = cmd.Run()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsLinkedWorktree returns true if the current directory is in a worktree added with 'git worktree add',
+// rather than in the main worktree of the repo
+func IsLinkedWorktree() (bool, error) {
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir", "--git-common-dir")
+	dirsBytes, err := cmd.Output()
+	if err != nil {
+		return false, err
+	}
+
+	dirs := strings.Split(strings.TrimSpace(string(dirsBytes)), "\n")
+	if len(dirs) < 2 {
+		return false, nil
+	}
+
+	commonDir, err := filepath.Abs(dirs[1])
+	if err != nil {
+		return false, err
+	}
+
+	return dirs[0] != commonDir, nil
+}
+
+// GetWorktrees returns the paths of every worktree of the current repo, starting with the main worktree
+func GetWorktrees() ([]string, error) {
+	cmd := exec.Command("git", "worktree", "list", "--porcelain")
+	listBytes, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var worktrees []string
+	for _, line := range strings.Split(string(listBytes), "\n") {
+		if strings.HasPrefix(line, "worktree ") {
+			worktrees = append(worktrees, strings.TrimPrefix(line, "worktree "))
+		}
+	}
+
+	return worktrees, nil
+}
@@ -0,0 +1,182 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// addTestWorktree makes a first commit in the current repo and adds a linked worktree for it, returning its path
+func addTestWorktree(t *testing.T, repo string) string {
+	t.Helper()
+
+	runGit(t, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "Initial commit")
+
+	linked := filepath.Join(filepath.Dir(repo), "linked")
+	runGit(t, "worktree", "add", "-q", linked)
+
+	return linked
+}
+
+func TestIsLinkedWorktree(t *testing.T) {
+	repo, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	linked := addTestWorktree(t, repo)
+	for _, dir := range []string{repo, linked} {
+		if err := os.Mkdir(filepath.Join(dir, "sub"), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want bool
+	}{
+		{"main worktree", repo, false},
+		{"inside the main worktree", filepath.Join(repo, "sub"), false},
+		{"main git dir", filepath.Join(repo, ".git"), false},
+		{"linked worktree", linked, true},
+		{"inside a linked worktree", filepath.Join(linked, "sub"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chdir(tt.dir); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := IsLinkedWorktree()
+			if err != nil {
+				t.Fatalf("IsLinkedWorktree() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsLinkedWorktree() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnableWorktreeConfig(t *testing.T) {
+	_, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	tests := []struct {
+		name    string
+		config  map[string]string
+		wantErr bool
+	}{
+		{"nothing set", map[string]string{}, false},
+		{"core.bare false", map[string]string{"core.bare": "false"}, false},
+		{"core.bare true", map[string]string{"core.bare": "true"}, true},
+		{"core.worktree", map[string]string{"core.worktree": ".."}, true},
+	}
+	// git init sets core.bare to false
+	runGit(t, "config", "--local", "--unset-all", "core.bare")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.config {
+				runGit(t, "config", "--local", key, value)
+			}
+			defer func() {
+				for key := range tt.config {
+					runGit(t, "config", "--local", "--unset-all", key)
+				}
+				runGit(t, "config", "--local", "extensions.worktreeConfig", "false")
+			}()
+
+			err := EnableWorktreeConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnableWorktreeConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			enabled, err := IsWorktreeConfigEnabled()
+			if err != nil {
+				t.Fatalf("IsWorktreeConfigEnabled() error = %v", err)
+			}
+			if enabled == tt.wantErr {
+				t.Errorf("IsWorktreeConfigEnabled() = %v after EnableWorktreeConfig(), want %v", enabled, !tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMovePairingToWorktreeConfig(t *testing.T) {
+	repo, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	linked := addTestWorktree(t, repo)
+
+	err := EnableWorktreeConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pairingPath := filepath.Join(os.Getenv("HOME"), ".gpair", "repo-abc123-template.txt")
+	userPath := filepath.Join(os.Getenv("HOME"), "template.txt")
+
+	tests := []struct {
+		name       string
+		template   string
+		wantShared string
+	}{
+		{"template set by gpair", pairingPath, ""},
+		{"template of the user", userPath, userPath},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chdir(repo); err != nil {
+				t.Fatal(err)
+			}
+
+			runGit(t, "config", "--local", "gpair.pairing", pairingPath)
+			runGit(t, "config", "--local", "gpair.originalTemplate", userPath)
+			runGit(t, "config", "--local", "commit.template", tt.template)
+			defer func() {
+				if err := os.Chdir(repo); err != nil {
+					t.Fatal(err)
+				}
+				runGit(t, "config", "--worktree", "--remove-section", "gpair")
+				if tt.wantShared == "" {
+					runGit(t, "config", "--worktree", "--unset", "commit.template")
+				} else {
+					runGit(t, "config", "--local", "--unset", "commit.template")
+				}
+			}()
+
+			err := MovePairingToWorktreeConfig()
+			if err != nil {
+				t.Fatalf("MovePairingToWorktreeConfig() error = %v", err)
+			}
+
+			want := map[string]string{
+				"gpair.pairing":          pairingPath,
+				"gpair.originalTemplate": userPath,
+				"commit.template":        tt.template,
+			}
+			for key, value := range want {
+				got, _ := getConfig(key)
+				if got != value {
+					t.Errorf("%s in the main worktree = %s, want %s", key, got, value)
+				}
+			}
+
+			if err := os.Chdir(linked); err != nil {
+				t.Fatal(err)
+			}
+
+			want = map[string]string{
+				"gpair.pairing":          "",
+				"gpair.originalTemplate": "",
+				"commit.template":        tt.wantShared,
+			}
+			for key, value := range want {
+				got, _ := getConfig(key)
+				if got != value {
+					t.Errorf("%s in a linked worktree = %s, want %s", key, got, value)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/store"
+)
+
+// Actions recorded in the journal
+const (
+	Pair   = "pair"
+	Join   = "join"
+	Leave  = "leave"
+	Solo   = "solo"
+	Pause  = "pause"
+	Resume = "resume"
+)
+
+// Event is a change to a pairing session, as recorded in the journal
+type Event struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Scope     string    `json:"scope"`
+	Repo      string    `json:"repo,omitempty"`
+	Aliases   []string  `json:"aliases,omitempty"`
+	Coauthors []string  `json:"coauthors"`
+}
+
+// Filter selects events from the journal. Zero values match every event.
+type Filter struct {
+	Repo         string
+	Collaborator string
+	Since        time.Time
+	Until        time.Time
+}
+
+// Journal is an abstraction over an append-only record of pairing events
+type Journal interface {
+	Record(event Event) error
+	Query(filter Filter) ([]Event, error)
+}
+
+type journal struct {
+	store store.Store
+}
+
+// NewJournal returns a journal that appends events to a file on disk
+func NewJournal() (Journal, error) {
+	store, err := store.NewFileStore("history.jsonl", store.HOME, ".gpair")
+	if err != nil {
+		return nil, err
+	}
+
+	return journal{store}, nil
+}
+
+func (j journal) Record(event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	jsonBytes, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return j.store.Append(append(jsonBytes, '\n'))
+}
+
+func (j journal) Query(filter Filter) ([]Event, error) {
+	journalBytes, err := j.store.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(bytes.NewReader(journalBytes))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var event Event
+		err = json.Unmarshal(scanner.Bytes(), &event)
+		if err != nil {
+			internal.PrintVerbose("Skipping unreadable line in %s: %s", j.store.GetPath(), scanner.Text())
+			continue
+		}
+
+		if filter.Matches(event) {
+			events = append(events, event)
+		}
+	}
+
+	return events, scanner.Err()
+}
+
+// Matches returns true if the event passes the filter.
+// A repo matches by its full path or its name, and a collaborator matches by alias.
+func (f Filter) Matches(event Event) bool {
+	if f.Repo != "" && f.Repo != event.Repo && f.Repo != filepath.Base(event.Repo) {
+		return false
+	}
+
+	if f.Collaborator != "" && !contains(event.Coauthors, f.Collaborator) && !contains(event.Aliases, f.Collaborator) {
+		return false
+	}
+
+	if !f.Since.IsZero() && event.Time.Before(f.Since) {
+		return false
+	}
+
+	if !f.Until.IsZero() && !event.Time.Before(f.Until) {
+		return false
+	}
+
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,60 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func populateJournal() Journal {
+	j := NewMockJournal()
+	start := time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	events := []Event{
+		{Time: start, Action: Pair, Scope: "local", Repo: "/src/billing", Aliases: []string{"a1"}, Coauthors: []string{"a1"}},
+		{Time: start.Add(time.Hour), Action: Join, Scope: "local", Repo: "/src/billing", Aliases: []string{"a2"}, Coauthors: []string{"a1", "a2"}},
+		{Time: start.Add(24 * time.Hour), Action: Pair, Scope: "global", Aliases: []string{"a3"}, Coauthors: []string{"a3"}},
+		{Time: start.Add(48 * time.Hour), Action: Solo, Scope: "local", Repo: "/src/api", Coauthors: []string{}},
+	}
+	for _, event := range events {
+		if err := j.Record(event); err != nil {
+			panic(err)
+		}
+	}
+
+	return j
+}
+
+func Test_journal_Query(t *testing.T) {
+	start := time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		filter      Filter
+		wantActions []string
+	}{
+		{"everything", Filter{}, []string{Pair, Join, Pair, Solo}},
+		{"repo by name", Filter{Repo: "billing"}, []string{Pair, Join}},
+		{"repo by path", Filter{Repo: "/src/api"}, []string{Solo}},
+		{"collaborator", Filter{Collaborator: "a2"}, []string{Join}},
+		{"since", Filter{Since: start.Add(time.Hour)}, []string{Join, Pair, Solo}},
+		{"until", Filter{Until: start.Add(24 * time.Hour)}, []string{Pair, Join}},
+		{"no match", Filter{Repo: "billing", Collaborator: "a3"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := populateJournal().Query(tt.filter)
+			if err != nil {
+				t.Fatalf("journal.Query() error = %v", err)
+			}
+
+			var gotActions []string
+			for _, event := range events {
+				gotActions = append(gotActions, event.Action)
+			}
+			if !reflect.DeepEqual(gotActions, tt.wantActions) {
+				t.Errorf("journal.Query() actions = %v, want %v", gotActions, tt.wantActions)
+			}
+		})
+	}
+}
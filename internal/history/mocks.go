@@ -0,0 +1,11 @@
+package history
+
+import (
+	"github.com/adavidalbertson/gpair/internal/store"
+)
+
+// NewMockJournal returns a Journal that holds events in memory instead of writing to disk
+// For testing purposes only
+func NewMockJournal() Journal {
+	return journal{&store.InMemoryStore{}}
+}
@@ -0,0 +1,11 @@
+package registry
+
+import (
+	"github.com/adavidalbertson/gpair/internal/store"
+)
+
+// NewMockRegistry returns a Registry that holds entries in memory instead of writing to disk
+// For testing purposes only
+func NewMockRegistry() Registry {
+	return registry{&store.InMemoryStore{}}
+}
@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/store"
+)
+
+// Scopes that a pairing can be set in
+const (
+	Global   = "global"
+	Dir      = "dir"
+	Local    = "local"
+	Worktree = "worktree"
+)
+
+// Entry is a scope where gpair has set a commit template or pairing file.
+// Clones sharing a key with gpair.repoKey set to remote each have their own entry, told apart by Path.
+type Entry struct {
+	Key    string    `json:"key"`
+	Scope  string    `json:"scope"`
+	Path   string    `json:"path,omitempty"`
+	Time   time.Time `json:"time"`
+	Active bool      `json:"active"`
+}
+
+// Registry is an abstraction over the record of every scope where gpair is pairing.
+// Changes are appended, so that gpair running in several repos at once does not lose any.
+type Registry interface {
+	Add(entry Entry) error
+	Remove(key, path string) error
+	List() ([]Entry, error)
+	Compact() error
+}
+
+type registry struct {
+	store store.Store
+}
+
+// NewRegistry returns a registry that appends changes to a file on disk
+func NewRegistry() (Registry, error) {
+	store, err := store.NewFileStore("registry.jsonl", store.HOME, ".gpair")
+	if err != nil {
+		return nil, err
+	}
+
+	return registry{store}, nil
+}
+
+func (r registry) Add(entry Entry) error {
+	entry.Active = true
+
+	return r.record(entry)
+}
+
+func (r registry) Remove(key, path string) error {
+	return r.record(Entry{Key: key, Path: path})
+}
+
+func (r registry) record(entry Entry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return r.store.Append(append(jsonBytes, '\n'))
+}
+
+// List returns the scopes where gpair is pairing, sorted by scope and path.
+// The time of each entry is when it was first added.
+func (r registry) List() ([]Entry, error) {
+	registryBytes, err := r.store.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	active := make(map[string]Entry)
+	scanner := bufio.NewScanner(bytes.NewReader(registryBytes))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var entry Entry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			internal.PrintVerbose("Skipping unreadable line in %s: %s", r.store.GetPath(), scanner.Text())
+			continue
+		}
+
+		id := entry.Key + "\x00" + entry.Path
+		if !entry.Active {
+			delete(active, id)
+			continue
+		}
+
+		if previous, ok := active[id]; ok {
+			entry.Time = previous.Time
+		}
+		active[id] = entry
+	}
+
+	entries := make([]Entry, 0, len(active))
+	for _, entry := range active {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Scope != entries[j].Scope {
+			return entries[i].Scope < entries[j].Scope
+		}
+		return entries[i].Path < entries[j].Path
+	})
+
+	return entries, scanner.Err()
+}
+
+// Compact rewrites the registry with only the scopes where gpair is still pairing
+func (r registry) Compact() error {
+	entries, err := r.List()
+	if err != nil {
+		return err
+	}
+
+	var registryBytes []byte
+	for _, entry := range entries {
+		jsonBytes, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		registryBytes = append(registryBytes, append(jsonBytes, '\n')...)
+	}
+
+	return r.store.Write(registryBytes)
+}
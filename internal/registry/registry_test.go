@@ -0,0 +1,59 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_registry_List(t *testing.T) {
+	start := time.Date(2020, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	r := NewMockRegistry()
+	entries := []Entry{
+		{Key: "gpair-global", Scope: Global, Time: start},
+		{Key: "api-1", Scope: Local, Path: "/src/api", Time: start},
+		{Key: "billing-2", Scope: Local, Path: "/src/billing", Time: start},
+		// Pairing again keeps the time the scope was first added
+		{Key: "api-1", Scope: Local, Path: "/src/api", Time: start.Add(time.Hour)},
+		// A clone sharing the key of another with gpair.repoKey set to remote
+		{Key: "api-1", Scope: Local, Path: "/tmp/api", Time: start},
+		{Key: "dir-acme-3", Scope: Dir, Path: "/work/acme", Time: start},
+	}
+	for _, entry := range entries {
+		if err := r.Add(entry); err != nil {
+			t.Fatalf("registry.Add() error = %v", err)
+		}
+	}
+
+	if err := r.Remove("billing-2", "/src/billing"); err != nil {
+		t.Fatalf("registry.Remove() error = %v", err)
+	}
+
+	want := []Entry{
+		{Key: "dir-acme-3", Scope: Dir, Path: "/work/acme", Time: start, Active: true},
+		{Key: "gpair-global", Scope: Global, Time: start, Active: true},
+		{Key: "api-1", Scope: Local, Path: "/src/api", Time: start, Active: true},
+		{Key: "api-1", Scope: Local, Path: "/tmp/api", Time: start, Active: true},
+	}
+
+	got, err := r.List()
+	if err != nil {
+		t.Fatalf("registry.List() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("registry.List() = %v, want %v", got, want)
+	}
+
+	if err := r.Compact(); err != nil {
+		t.Fatalf("registry.Compact() error = %v", err)
+	}
+
+	got, err = r.List()
+	if err != nil {
+		t.Fatalf("registry.List() after Compact() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("registry.List() after Compact() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,187 @@
+package session
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/store"
+)
+
+// Manager is an abstraction that allows operations on a persisted session
+// The last session replaced or cleared is kept, so that it can be resumed with GetLast.
+// Sessions bound to a branch are kept by branch name, so they can be applied again when it is checked out.
+type Manager interface {
+	Get() (Session, error)
+	GetLast() (Session, error)
+	Save(session Session) error
+	Clear() error
+	GetBranches() (map[string]Session, error)
+	SaveBranches(branches map[string]Session) error
+}
+
+type manager struct {
+	store       store.Store
+	lastStore   store.Store
+	branchStore store.Store
+}
+
+// NewManager returns a manager that persists the session with the given key to disk
+func NewManager(key string) (Manager, error) {
+	sessionStore, err := store.NewFileStore(key+".json", store.HOME, ".gpair", "sessions")
+	if err != nil {
+		return nil, err
+	}
+
+	lastStore, err := store.NewFileStore(key+".last.json", store.HOME, ".gpair", "sessions")
+	if err != nil {
+		return nil, err
+	}
+
+	branchStore, err := store.NewFileStore(key+".branches.json", store.HOME, ".gpair", "sessions")
+	if err != nil {
+		return nil, err
+	}
+
+	return manager{sessionStore, lastStore, branchStore}, nil
+}
+
+// Peek returns the session saved under key, or an empty session if there is none, without creating any files
+func Peek(key string) (Session, error) {
+	jsonBytes, err := store.ReadFile(key+".json", store.HOME, ".gpair", "sessions")
+	if err != nil || len(jsonBytes) == 0 {
+		return Session{}, err
+	}
+
+	var session Session
+	err = json.Unmarshal(jsonBytes, &session)
+	if err != nil {
+		internal.PrintVerbose("Failed to parse session file for %s.", key)
+		return Session{}, nil
+	}
+
+	session.setAliases()
+
+	return session, nil
+}
+
+func (m manager) Get() (Session, error) {
+	return m.load(m.store)
+}
+
+func (m manager) GetLast() (Session, error) {
+	return m.load(m.lastStore)
+}
+
+func (m manager) load(store store.Store) (Session, error) {
+	jsonBytes, err := store.Read()
+	if err != nil {
+		return Session{}, err
+	}
+
+	if len(jsonBytes) == 0 {
+		return Session{}, nil
+	}
+
+	var session Session
+	err = json.Unmarshal(jsonBytes, &session)
+	if err != nil {
+		internal.PrintVerbose("Failed to parse session file at %s. Starting a new session.", store.GetPath())
+		return Session{}, nil
+	}
+
+	session.setAliases()
+
+	return session, nil
+}
+
+// PeekBranches returns the sessions bound to branches saved under key, without creating any files
+func PeekBranches(key string) (map[string]Session, error) {
+	jsonBytes, err := store.ReadFile(key+".branches.json", store.HOME, ".gpair", "sessions")
+	if err != nil {
+		return make(map[string]Session), err
+	}
+
+	return parseBranches(jsonBytes, key)
+}
+
+func (m manager) GetBranches() (map[string]Session, error) {
+	jsonBytes, err := m.branchStore.Read()
+	if err != nil {
+		return make(map[string]Session), err
+	}
+
+	return parseBranches(jsonBytes, m.branchStore.GetPath())
+}
+
+// parseBranches parses the sessions bound to branches, forgetting them all if they cannot be parsed
+func parseBranches(jsonBytes []byte, source string) (map[string]Session, error) {
+	branches := make(map[string]Session)
+	if len(jsonBytes) == 0 {
+		return branches, nil
+	}
+
+	err := json.Unmarshal(jsonBytes, &branches)
+	if err != nil {
+		internal.PrintVerbose("Failed to parse branch sessions file at %s. Forgetting branch pairings.", source)
+		return make(map[string]Session), nil
+	}
+
+	for branch, session := range branches {
+		session.setAliases()
+		branches[branch] = session
+	}
+
+	return branches, nil
+}
+
+func (m manager) SaveBranches(branches map[string]Session) error {
+	if len(branches) == 0 {
+		return m.branchStore.Write([]byte{})
+	}
+
+	jsonBytes, err := json.Marshal(branches)
+	if err != nil {
+		return err
+	}
+
+	return m.branchStore.Write(jsonBytes)
+}
+
+func (m manager) Save(session Session) error {
+	err := m.keepIfReplaced(session)
+	if err != nil {
+		return err
+	}
+
+	jsonBytes, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return m.store.Write(jsonBytes)
+}
+
+func (m manager) Clear() error {
+	err := m.keepIfReplaced(Session{})
+	if err != nil {
+		return err
+	}
+
+	return m.store.Write([]byte{})
+}
+
+// keepIfReplaced saves the current session as the last one if the next session has different coauthors
+func (m manager) keepIfReplaced(next Session) error {
+	current, err := m.Get()
+	if err != nil || current.IsEmpty() || reflect.DeepEqual(current.Aliases, next.Aliases) {
+		return err
+	}
+
+	jsonBytes, err := m.store.Read()
+	if err != nil {
+		return err
+	}
+
+	return m.lastStore.Write(jsonBytes)
+}
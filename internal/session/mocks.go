@@ -0,0 +1,11 @@
+package session
+
+import (
+	"github.com/adavidalbertson/gpair/internal/store"
+)
+
+// NewMockManager returns a Manager that holds the session in memory instead of writing to disk
+// For testing purposes only
+func NewMockManager() Manager {
+	return manager{&store.InMemoryStore{}, &store.InMemoryStore{}, &store.InMemoryStore{}}
+}
@@ -0,0 +1,127 @@
+package session
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+// Session is the persisted state of a pairing in one scope, which is either a repo or global
+type Session struct {
+	Aliases   []string                       `json:"aliases"`
+	Coauthors map[string]config.Collaborator `json:"coauthors"`
+	Start     time.Time                      `json:"start"`
+	Expires   time.Time                      `json:"expires"`
+	Confirmed time.Time                      `json:"confirmed"`
+	Paused    bool                           `json:"paused"`
+	Branch    string                         `json:"branch,omitempty"`
+	Style     string                         `json:"style,omitempty"`
+	// Trailers holds the key of the trailer crediting each coauthor not credited with config.DefaultTrailer,
+	// since the roster does not save it with the collaborator
+	Trailers map[string]string `json:"trailers,omitempty"`
+}
+
+// NewSession returns a session with the given coauthors, starting now
+func NewSession(coauthors ...config.Collaborator) Session {
+	session := Session{
+		Coauthors: make(map[string]config.Collaborator),
+		Start:     time.Now(),
+	}
+	session.Add(coauthors...)
+
+	return session
+}
+
+// IsEmpty returns true if nobody is in the session
+func (s Session) IsEmpty() bool {
+	return len(s.Aliases) == 0
+}
+
+// IsExpired returns true if the session has an expiry time that has passed
+func (s Session) IsExpired(now time.Time) bool {
+	return !s.Expires.IsZero() && !now.Before(s.Expires)
+}
+
+// GetCoauthors returns the coauthors in the order they joined the session
+func (s Session) GetCoauthors() []config.Collaborator {
+	var coauthors []config.Collaborator
+	for _, alias := range s.Aliases {
+		coauthors = append(coauthors, s.Coauthors[alias])
+	}
+
+	return coauthors
+}
+
+// Add adds coauthors to the session, updating the details of any already in it
+func (s *Session) Add(coauthors ...config.Collaborator) {
+	if s.Coauthors == nil {
+		s.Coauthors = make(map[string]config.Collaborator)
+	}
+
+	for _, coauthor := range coauthors {
+		if _, exists := s.Coauthors[coauthor.Alias]; !exists {
+			s.Aliases = append(s.Aliases, coauthor.Alias)
+		}
+		s.Coauthors[coauthor.Alias] = coauthor
+
+		if coauthor.Trailer == "" {
+			delete(s.Trailers, coauthor.Alias)
+			continue
+		}
+		if s.Trailers == nil {
+			s.Trailers = make(map[string]string)
+		}
+		s.Trailers[coauthor.Alias] = coauthor.Trailer
+	}
+}
+
+// Remove removes the coauthors with the given aliases from the session, and returns the aliases that were not in it
+func (s *Session) Remove(aliases ...string) []string {
+	var missing []string
+	for _, alias := range aliases {
+		if _, exists := s.Coauthors[alias]; !exists {
+			missing = append(missing, alias)
+			continue
+		}
+
+		delete(s.Coauthors, alias)
+		delete(s.Trailers, alias)
+		for i, a := range s.Aliases {
+			if a == alias {
+				s.Aliases = append(s.Aliases[:i], s.Aliases[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return missing
+}
+
+// setAliases fills in the aliases and trailers of the coauthors, which are only saved as the keys of Coauthors
+// and in Trailers
+func (s *Session) setAliases() {
+	for alias, coauthor := range s.Coauthors {
+		coauthor.Alias = alias
+		coauthor.Trailer = s.Trailers[alias]
+		s.Coauthors[alias] = coauthor
+	}
+}
+
+// ParseDuration parses a duration like "3h" or "90m", also accepting a number of days like "2d"
+func ParseDuration(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a duration like '3h' or '2d'", value)
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,272 @@
+package session
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+func TestSession_Add(t *testing.T) {
+	a1 := config.NewCollaborator("a1", "name1", "email1")
+	a2 := config.NewCollaborator("a2", "name2", "email2")
+	a2Updated := config.NewCollaborator("a2", "name2", "new-email2")
+	a2Reviewer := config.NewCollaborator("a2", "name2", "email2")
+	a2Reviewer.Trailer = "Reviewed-by"
+
+	tests := []struct {
+		name  string
+		start []config.Collaborator
+		add   []config.Collaborator
+		want  []config.Collaborator
+	}{
+		{"add to empty", nil, []config.Collaborator{a1}, []config.Collaborator{a1}},
+		{"add new", []config.Collaborator{a1}, []config.Collaborator{a2}, []config.Collaborator{a1, a2}},
+		{"add existing", []config.Collaborator{a1, a2}, []config.Collaborator{a1}, []config.Collaborator{a1, a2}},
+		{"update existing", []config.Collaborator{a1, a2}, []config.Collaborator{a2Updated}, []config.Collaborator{a1, a2Updated}},
+		{"update trailer", []config.Collaborator{a1, a2Reviewer}, []config.Collaborator{a2}, []config.Collaborator{a1, a2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSession(tt.start...)
+			s.Add(tt.add...)
+			if got := s.GetCoauthors(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Session.GetCoauthors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSession_Remove(t *testing.T) {
+	a1 := config.NewCollaborator("a1", "name1", "email1")
+	a2 := config.NewCollaborator("a2", "name2", "email2")
+	a3 := config.NewCollaborator("a3", "name3", "email3")
+
+	tests := []struct {
+		name        string
+		remove      []string
+		want        []config.Collaborator
+		wantMissing []string
+	}{
+		{"remove one", []string{"a2"}, []config.Collaborator{a1, a3}, nil},
+		{"remove all", []string{"a3", "a1", "a2"}, nil, nil},
+		{"remove missing", []string{"a1", "a4"}, []config.Collaborator{a2, a3}, []string{"a4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSession(a1, a2, a3)
+			gotMissing := s.Remove(tt.remove...)
+			if !reflect.DeepEqual(gotMissing, tt.wantMissing) {
+				t.Errorf("Session.Remove() = %v, want %v", gotMissing, tt.wantMissing)
+			}
+			if got := s.GetCoauthors(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Session.GetCoauthors() = %v, want %v", got, tt.want)
+			}
+			if s.IsEmpty() != (len(tt.want) == 0) {
+				t.Errorf("Session.IsEmpty() = %v, want %v", s.IsEmpty(), len(tt.want) == 0)
+			}
+		})
+	}
+}
+
+func Test_manager_SaveGet(t *testing.T) {
+	m := NewMockManager()
+
+	got, err := m.Get()
+	if err != nil || !got.IsEmpty() {
+		t.Fatalf("manager.Get() = %v, error = %v, want empty session", got, err)
+	}
+
+	// The trailer is saved with the session, since the roster does not save it
+	a2 := config.NewCollaborator("a2", "name2", "email2")
+	a2.Trailer = "Reviewed-by"
+	want := NewSession(config.NewCollaborator("a1", "name1", "email1"), a2)
+	err = m.Save(want)
+	if err != nil {
+		t.Fatalf("manager.Save() error = %v", err)
+	}
+
+	got, err = m.Get()
+	if err != nil {
+		t.Fatalf("manager.Get() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.GetCoauthors(), want.GetCoauthors()) || !got.Start.Equal(want.Start) {
+		t.Errorf("manager.Get() = %v, want %v", got, want)
+	}
+
+	err = m.Clear()
+	if err != nil {
+		t.Fatalf("manager.Clear() error = %v", err)
+	}
+
+	got, err = m.Get()
+	if err != nil || !got.IsEmpty() {
+		t.Errorf("manager.Get() after Clear() = %v, error = %v, want empty session", got, err)
+	}
+}
+
+func TestSession_IsExpired(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		expires time.Time
+		want    bool
+	}{
+		{"no expiry", time.Time{}, false},
+		{"future", now.Add(time.Minute), false},
+		{"now", now, true},
+		{"past", now.Add(-time.Minute), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Session{Expires: tt.expires}
+			if got := s.IsExpired(now); got != tt.want {
+				t.Errorf("Session.IsExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"hours", "3h", 3 * time.Hour, false},
+		{"minutes", "90m", 90 * time.Minute, false},
+		{"days", "2d", 48 * time.Hour, false},
+		{"garbage", "all day", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDuration(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseDuration() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_manager_GetLast(t *testing.T) {
+	a1 := config.NewCollaborator("a1", "name1", "email1")
+	a2 := config.NewCollaborator("a2", "name2", "email2")
+
+	m := NewMockManager()
+
+	first := NewSession(a1)
+	if err := m.Save(first); err != nil {
+		t.Fatalf("manager.Save() error = %v", err)
+	}
+
+	// Saving the same coauthors again, e.g. when pausing, does not replace the session
+	first.Paused = true
+	if err := m.Save(first); err != nil {
+		t.Fatalf("manager.Save() error = %v", err)
+	}
+
+	if last, _ := m.GetLast(); !last.IsEmpty() {
+		t.Errorf("manager.GetLast() = %v, want empty session", last)
+	}
+
+	if err := m.Save(NewSession(a2)); err != nil {
+		t.Fatalf("manager.Save() error = %v", err)
+	}
+
+	if last, _ := m.GetLast(); !reflect.DeepEqual(last.Aliases, []string{"a1"}) {
+		t.Errorf("manager.GetLast() = %v, want session with a1", last)
+	}
+
+	if err := m.Clear(); err != nil {
+		t.Fatalf("manager.Clear() error = %v", err)
+	}
+
+	if last, _ := m.GetLast(); !reflect.DeepEqual(last.Aliases, []string{"a2"}) {
+		t.Errorf("manager.GetLast() = %v, want session with a2", last)
+	}
+}
+
+func Test_manager_SaveGetBranches(t *testing.T) {
+	m := NewMockManager()
+
+	got, err := m.GetBranches()
+	if err != nil || len(got) != 0 {
+		t.Fatalf("manager.GetBranches() = %v, error = %v, want none", got, err)
+	}
+
+	feature := NewSession(config.NewCollaborator("a1", "name1", "email1"))
+	feature.Branch = "feature/x"
+	err = m.SaveBranches(map[string]Session{feature.Branch: feature})
+	if err != nil {
+		t.Fatalf("manager.SaveBranches() error = %v", err)
+	}
+
+	got, err = m.GetBranches()
+	if err != nil {
+		t.Fatalf("manager.GetBranches() error = %v", err)
+	}
+	if !reflect.DeepEqual(got[feature.Branch].GetCoauthors(), feature.GetCoauthors()) || got[feature.Branch].Branch != feature.Branch {
+		t.Errorf("manager.GetBranches() = %v, want %v", got, feature)
+	}
+
+	err = m.SaveBranches(nil)
+	if err != nil {
+		t.Fatalf("manager.SaveBranches() error = %v", err)
+	}
+
+	got, err = m.GetBranches()
+	if err != nil || len(got) != 0 {
+		t.Errorf("manager.GetBranches() after clearing = %v, error = %v, want none", got, err)
+	}
+}
+
+func TestPeekBranches(t *testing.T) {
+	home, err := ioutil.TempDir("", "gpair_home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", home)
+	defer os.Setenv("HOME", oldHome)
+
+	got, err := PeekBranches("repo-abc123")
+	if err != nil || len(got) != 0 {
+		t.Fatalf("PeekBranches() = %v, error = %v, want none", got, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".gpair")); !os.IsNotExist(err) {
+		t.Errorf("PeekBranches() created %s", filepath.Join(home, ".gpair"))
+	}
+
+	m, err := NewManager("repo-abc123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	feature := NewSession(config.NewCollaborator("a1", "name1", "email1"))
+	feature.Branch = "feature/x"
+	err = m.SaveBranches(map[string]Session{feature.Branch: feature})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = PeekBranches("repo-abc123")
+	if err != nil {
+		t.Fatalf("PeekBranches() error = %v", err)
+	}
+	if !reflect.DeepEqual(got[feature.Branch].GetCoauthors(), feature.GetCoauthors()) {
+		t.Errorf("PeekBranches() = %v, want %v", got, feature)
+	}
+}
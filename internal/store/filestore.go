@@ -23,6 +23,7 @@ const (
 type Store interface {
 	Read() ([]byte, error)
 	Write([]byte) error
+	Append([]byte) error
 	GetPath() string
 }
 
@@ -77,6 +78,24 @@ func NewFileStore(filename string, startDirType int, dirPath ...string) (Store,
 	return fs, nil
 }
 
+// ReadFile returns the contents of a file without creating it or its directories, or nil if it does not exist
+func ReadFile(filename string, startDirType int, dirPath ...string) ([]byte, error) {
+	path, err := getStartDir(startDirType)
+	if err != nil {
+		return nil, err
+	}
+
+	path = filepath.Join(append(append([]string{path}, dirPath...), filename)...)
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, NewErrFileInaccessible(err, path)
+	}
+
+	return contents, nil
+}
+
 func getStartDir(startDirType int) (startDir string, err error) {
 	switch startDirType {
 	case HOME:
@@ -199,6 +218,21 @@ func (fs *fileStore) Write(jsonBytes []byte) error {
 	return nil
 }
 
+func (fs *fileStore) Append(bytes []byte) error {
+	file, err := os.OpenFile(fs.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return NewErrFileInaccessible(err, fs.path)
+	}
+	defer file.Close()
+
+	_, err = file.Write(bytes)
+	if err != nil {
+		return errors.Wrapf(err, "failed to append to file at %s", fs.path)
+	}
+
+	return nil
+}
+
 func (fs *fileStore) GetPath() string {
 	return fs.path
 }
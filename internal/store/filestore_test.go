@@ -119,6 +119,45 @@ func TestNewFileStore(t *testing.T) {
 	}
 }
 
+func TestReadFile(t *testing.T) {
+
+	setUp()
+
+	type args struct {
+		filename     string
+		startDirType int
+		dirPath      []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []byte
+		wantErr bool
+	}{
+		{"existing file", args{existingFile, ROOT, []string{testingPath, existingDir}}, []byte(existingFileContents), false},
+		{"missing file", args{"new_file.txt", ROOT, []string{testingPath, existingDir}}, nil, false},
+		{"missing dir", args{"new_file.txt", ROOT, []string{testingPath, "new_dir"}}, nil, false},
+		{"invalid dir type", args{existingFile, -1, []string{existingDirPath}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFile(tt.args.filename, tt.args.startDirType, tt.args.dirPath...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadFile() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Reading must never create what is missing
+	if _, err := os.Stat(filepath.Join(testingPath, "new_dir")); !os.IsNotExist(err) {
+		t.Errorf("ReadFile() created %s", filepath.Join(testingPath, "new_dir"))
+	}
+}
+
 func Test_fileStore_fileExists(t *testing.T) {
 
 	setUp()
@@ -283,3 +322,24 @@ func Test_fileStore_restoreBackup(t *testing.T) {
 		})
 	}
 }
+
+func Test_fileStore_Append(t *testing.T) {
+
+	setUp()
+
+	fs := &fileStore{filepath.Join(existingDirPath, "appended_file.txt")}
+
+	for _, line := range []string{"first\n", "second\n"} {
+		if err := fs.Append([]byte(line)); err != nil {
+			t.Fatalf("fileStore.Append() error = %v", err)
+		}
+	}
+
+	got, err := fs.Read()
+	if err != nil {
+		t.Fatalf("fileStore.Read() error = %v", err)
+	}
+	if string(got) != "first\nsecond\n" {
+		t.Errorf("fileStore.Read() = %q, want %q", got, "first\nsecond\n")
+	}
+}
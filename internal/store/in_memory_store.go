@@ -17,6 +17,12 @@ func (ims *InMemoryStore) Write(bytes []byte) error {
 	return nil
 }
 
+// Append adds bytes to the end of the store
+func (ims *InMemoryStore) Append(bytes []byte) error {
+	ims.bytes = append(ims.bytes, bytes...)
+	return nil
+}
+
 // GetPath is just here to fulfil the interface contract
 func (ims *InMemoryStore) GetPath() string {
 	return "This is an in-memory store not backed by a file on disk."
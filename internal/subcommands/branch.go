@@ -0,0 +1,294 @@
+package subcommands
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// repoPairing is the key under which the pairing for the whole repo is kept while a branch pairing replaces it
+const repoPairing = ""
+
+// bindToBranch binds the session to the current branch, so that it is only used while the branch is checked out.
+// It installs the post-checkout hook that switches pairings when another branch is checked out.
+func bindToBranch(current *session.Session) error {
+	branch, err := git.GetCurrentBranch()
+	if err != nil {
+		return err
+	}
+
+	if branch == "" {
+		fmt.Println("HEAD is detached, so there is no branch to pair on. Check out a branch first.")
+		exitWithError()
+	}
+
+	current.Branch = branch
+
+	return installCheckoutHook()
+}
+
+// saveSession saves the session, and the pairing for its branch if it is bound to one.
+// A pairing for the whole repo is set aside while a session bound to a branch replaces it.
+func saveSession(sessions session.Manager, current session.Session) error {
+	previous, err := sessions.Get()
+	if err != nil || current.Branch == "" {
+		if err == nil {
+			err = sessions.Save(current)
+		}
+
+		return err
+	}
+
+	err = sessions.Save(current)
+	if err != nil {
+		return err
+	}
+
+	branches, err := sessions.GetBranches()
+	if err != nil {
+		return err
+	}
+
+	if previous.Branch == "" && !previous.IsEmpty() {
+		branches[repoPairing] = previous
+	}
+	branches[current.Branch] = current
+
+	return sessions.SaveBranches(branches)
+}
+
+// unbindSession forgets the pairing bound to the current branch, if any, along with the pairing for the whole repo it replaced.
+// It is used before a new pairing for the whole repo replaces them.
+func unbindSession(sessions session.Manager) error {
+	current, err := sessions.Get()
+	if err != nil || current.Branch == "" {
+		return err
+	}
+
+	err = forgetBranch(sessions, current.Branch)
+	if err != nil {
+		return err
+	}
+
+	return forgetBranch(sessions, repoPairing)
+}
+
+// forgetBranch removes the pairing bound to the given branch, if any
+func forgetBranch(sessions session.Manager, branch string) error {
+	branches, err := sessions.GetBranches()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := branches[branch]; !ok {
+		return nil
+	}
+
+	delete(branches, branch)
+
+	return sessions.SaveBranches(branches)
+}
+
+// getBranchSessions returns the pairings bound to branches of the current repo,
+// forgetting those of branches that have been deleted and those that have expired
+func getBranchSessions(sessions session.Manager) (map[string]session.Session, error) {
+	branches, err := sessions.GetBranches()
+	if err != nil {
+		return branches, err
+	}
+
+	pruned, err := pruneBranchSessions(branches)
+	if err != nil || !pruned {
+		return branches, err
+	}
+
+	return branches, sessions.SaveBranches(branches)
+}
+
+// peekBranchSessions returns the pairings bound to branches of the current repo like getBranchSessions,
+// but only reads them, without forgetting any or creating any state
+func peekBranchSessions() (map[string]session.Session, error) {
+	key, err := lookupSessionKey(false)
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := session.PeekBranches(key)
+	if err != nil {
+		return branches, err
+	}
+
+	_, err = pruneBranchSessions(branches)
+
+	return branches, err
+}
+
+// pruneBranchSessions removes the pairings of branches that have been deleted and those that have expired,
+// and returns true if it removed any
+func pruneBranchSessions(branches map[string]session.Session) (bool, error) {
+	if len(branches) == 0 {
+		return false, nil
+	}
+
+	existing, err := git.GetBranches()
+	if err != nil {
+		return false, err
+	}
+
+	pruned := false
+	for branch, bound := range branches {
+		if (branch != repoPairing && !contains(existing, branch)) || bound.IsExpired(time.Now()) {
+			internal.PrintVerbose("Forgetting the pairing on branch %s", branch)
+			delete(branches, branch)
+			pruned = true
+		}
+	}
+
+	return pruned, nil
+}
+
+// followBranch switches the local pairing to the one bound to the checked out branch.
+// If there is none, a pairing bound to the previous branch is stopped, and the pairing for the whole repo
+// that it replaced is put back.
+func followBranch() error {
+	if _, err := git.GetRepoRoot(); err != nil {
+		return nil
+	}
+
+	branch, err := git.GetCurrentBranch()
+	if err != nil {
+		return err
+	}
+
+	key := getSessionKey(false)
+
+	sessions, err := session.NewManager(key)
+	if err != nil {
+		return err
+	}
+
+	branches, err := getBranchSessions(sessions)
+	if err != nil {
+		return err
+	}
+
+	current, err := sessions.Get()
+	if err != nil {
+		return err
+	}
+
+	if current.Branch == branch {
+		return nil
+	}
+
+	if bound, ok := branches[branch]; ok && branch != repoPairing {
+		internal.PrintVerbose("Switching to the pairing on branch %s", branch)
+		return resumeSession(key, sessions, bound)
+	}
+
+	if current.Branch == "" {
+		return nil
+	}
+
+	if repo, ok := branches[repoPairing]; ok {
+		delete(branches, repoPairing)
+		err = sessions.SaveBranches(branches)
+		if err != nil {
+			return err
+		}
+
+		internal.PrintVerbose("Switching back to the pairing for the whole repo")
+		return resumeSession(key, sessions, repo)
+	}
+
+	internal.PrintVerbose("Stopping the pairing on branch %s", current.Branch)
+
+	err = git.UnsetPairing(false)
+	if err != nil {
+		return err
+	}
+
+	err = git.RestoreTemplate(false)
+	if err != nil {
+		return err
+	}
+
+	return sessions.Clear()
+}
+
+// resumeSession makes a local session saved before the current one again, keeping it paused if it was
+func resumeSession(key string, sessions session.Manager, saved session.Session) error {
+	if saved.Paused {
+		return pauseSession(sessions, saved, false)
+	}
+
+	return applySession(key, sessions, saved, false)
+}
+
+// installCheckoutHook installs the post-checkout hook that runs followBranch, if it is not already run in the current repo
+func installCheckoutHook() error {
+	gpairHooksDir, err := git.GetGlobalHooksDir()
+	if err != nil {
+		return err
+	}
+
+	hooksDir, err := git.GetHooksDir()
+	if err != nil {
+		return err
+	}
+
+	if hooksDir == gpairHooksDir {
+		internal.PrintVerbose("The global gpair hooks in %s already follow branches in this repo", gpairHooksDir)
+		return nil
+	}
+
+	isGpair, err := git.IsGpairHook(filepath.Join(hooksDir, git.PostCheckoutHook))
+	if err != nil || isGpair {
+		return err
+	}
+
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	localHooksPath, err := git.GetHooksPath(false)
+	if err != nil {
+		return err
+	}
+
+	globalHooksPath, err := git.GetHooksPath(true)
+	if err != nil {
+		return err
+	}
+
+	manager := git.DetectHookManager(repoRoot, hooksDir, git.PostCheckoutHook)
+
+	// Don't write into a global hooks directory that is not gpair's
+	if manager != "" || (localHooksPath == "" && globalHooksPath != "") {
+		printCheckoutHookSnippet(manager)
+		return nil
+	}
+
+	hookPath, err := git.InstallHook(hooksDir, git.PostCheckoutHook, false)
+	if err != nil {
+		if _, ok := err.(*git.ErrHookExists); ok {
+			printCheckoutHookSnippet("")
+			return nil
+		}
+
+		return err
+	}
+	internal.PrintVerbose("Installed %s hook at %s", git.PostCheckoutHook, hookPath)
+
+	return nil
+}
+
+func printCheckoutHookSnippet(manager string) {
+	fmt.Println("To switch pairings when you check out another branch, gpair needs to run as a post-checkout hook.")
+	fmt.Println(git.HookManagerSnippet(manager, git.PostCheckoutHook))
+}
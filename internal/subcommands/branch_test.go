@@ -0,0 +1,162 @@
+package subcommands
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+func Test_followBranch(t *testing.T) {
+	_, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	commitFile(t, "a.txt", "1", "first")
+	runGit(t, "branch", "feature")
+	runGit(t, "branch", "other")
+
+	alice := config.NewCollaborator("alice", "Alice", "alice@example.com")
+	bob := config.NewCollaborator("bob", "Bob", "bob@example.com")
+	cy := config.NewCollaborator("cy", "Cy", "cy@example.com")
+
+	key := getSessionKey(false)
+	sessions, err := session.NewManager(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkout switches to the branch, and runs followBranch as the post-checkout hook would
+	checkout := func(branch string) {
+		t.Helper()
+
+		runGit(t, "checkout", "-q", branch)
+		if err := followBranch(); err != nil {
+			t.Fatalf("followBranch() on %s error = %v", branch, err)
+		}
+	}
+
+	// wantPairing checks the aliases and branch of the session, and who the template credits
+	wantPairing := func(step, branch string, coauthors ...config.Collaborator) {
+		t.Helper()
+
+		current, err := sessions.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var aliases []string
+		for _, coauthor := range coauthors {
+			aliases = append(aliases, coauthor.Alias)
+		}
+		if !reflect.DeepEqual(current.Aliases, aliases) || current.Branch != branch {
+			t.Errorf("%s: pairing with %v on branch %q, want %v on branch %q", step, current.Aliases, current.Branch, aliases, branch)
+		}
+
+		templatePath, err := git.GetTemplate(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(coauthors) == 0 {
+			if templatePath != "" {
+				t.Errorf("%s: commit.template = %s, want it unset", step, templatePath)
+			}
+			return
+		}
+
+		template, err := ioutil.ReadFile(templatePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, collaborator := range []config.Collaborator{alice, bob, cy} {
+			want := false
+			for _, coauthor := range coauthors {
+				want = want || coauthor.Alias == collaborator.Alias
+			}
+
+			if got := strings.Contains(string(template), collaborator.Email); got != want {
+				t.Errorf("%s: template credits %s = %v, want %v", step, collaborator.Alias, got, want)
+			}
+		}
+	}
+
+	// wantBranches checks which branches have a pairing bound to them, including repoPairing
+	wantBranches := func(step string, want ...string) {
+		t.Helper()
+
+		branches, err := sessions.GetBranches()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(branches) != len(want) {
+			t.Errorf("%s: pairings bound to %d branches, want %q", step, len(branches), want)
+		}
+		for _, branch := range want {
+			if _, ok := branches[branch]; !ok {
+				t.Errorf("%s: no pairing bound to %q, want %q", step, branch, want)
+			}
+		}
+	}
+
+	err = applySession(key, sessions, session.NewSession(alice), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPairing("pair for the repo", "", alice)
+
+	checkout("feature")
+	wantPairing("check out a branch without a pairing", "", alice)
+
+	bound := session.NewSession(bob)
+	bound.Branch = "feature"
+	err = applySession(key, sessions, bound, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPairing("pair on the branch", "feature", bob)
+	wantBranches("pair on the branch", repoPairing, "feature")
+
+	checkout("other")
+	wantPairing("leave the branch", "", alice)
+	wantBranches("leave the branch", "feature")
+
+	checkout("feature")
+	wantPairing("come back to the branch", "feature", bob)
+
+	// Pairing for the whole repo again replaces the pairing on the branch
+	err = unbindSession(sessions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = applySession(key, sessions, session.NewSession(cy), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantPairing("unbind", "", cy)
+	wantBranches("unbind")
+
+	checkout("other")
+	wantPairing("leave the unbound branch", "", cy)
+
+	// Without a pairing for the whole repo, leaving the branch stops pairing
+	err = endSession(sessions, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkout("feature")
+	bound.Branch = "feature"
+	err = applySession(key, sessions, bound, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBranches("pair on the branch after solo", "feature")
+
+	checkout("other")
+	wantPairing("leave the branch after solo", "")
+}
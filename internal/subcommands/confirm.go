@@ -0,0 +1,249 @@
+package subcommands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// needsConfirmation returns true if the gpair.confirm setting calls for asking whether the session is still going.
+// The setting is "daily", a duration such as "4h", or both separated by a comma.
+// Time is counted from the last confirmation, or from the start of the session.
+func needsConfirmation(current session.Session, setting string, now time.Time) (bool, error) {
+	last := current.Confirmed
+	if last.IsZero() {
+		last = current.Start
+	}
+
+	for _, rule := range strings.Split(setting, ",") {
+		rule = strings.TrimSpace(rule)
+
+		switch rule {
+		case "", "false", "never":
+			continue
+
+		case "daily":
+			lastYear, lastMonth, lastDay := last.Local().Date()
+			year, month, day := now.Local().Date()
+			if lastYear != year || lastMonth != month || lastDay != day {
+				return true, nil
+			}
+
+		default:
+			threshold, err := session.ParseDuration(rule)
+			if err != nil {
+				return false, fmt.Errorf("invalid gpair.confirm: %v", err)
+			}
+
+			if now.Sub(last) >= threshold {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// promptConfirmation asks whether the session is still going, and lets the user toggle coauthors on "edit".
+// It returns the coauthors to keep, which is empty if the user is no longer pairing.
+func promptConfirmation(in *bufio.Reader, out io.Writer, coauthors []config.Collaborator) ([]config.Collaborator, error) {
+	fmt.Fprintf(out, "Still pairing with %s? [Y/n/edit] ", describeCoauthors(coauthors))
+
+	answer, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return coauthors, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "n", "no":
+		return nil, nil
+
+	case "e", "edit":
+		return promptToggle(in, out, coauthors)
+	}
+
+	return coauthors, nil
+}
+
+// promptToggle lets the user toggle coauthors on and off by number until they enter an empty line
+func promptToggle(in *bufio.Reader, out io.Writer, coauthors []config.Collaborator) ([]config.Collaborator, error) {
+	keep := make([]bool, len(coauthors))
+	for i := range keep {
+		keep[i] = true
+	}
+
+	for {
+		for i, coauthor := range coauthors {
+			mark := " "
+			if keep[i] {
+				mark = "x"
+			}
+			fmt.Fprintf(out, "  %d) [%s] %s %s <%s>\n", i+1, mark, coauthor.Alias, coauthor.Name, coauthor.Email)
+		}
+		fmt.Fprint(out, "Toggle which? (numbers separated by spaces, empty to finish) ")
+
+		line, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return coauthors, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			break
+		}
+
+		for _, field := range fields {
+			n, convErr := strconv.Atoi(field)
+			if convErr != nil || n < 1 || n > len(coauthors) {
+				fmt.Fprintf(out, "'%s' is not one of the numbers above\n", field)
+				continue
+			}
+			keep[n-1] = !keep[n-1]
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	var kept []config.Collaborator
+	for i, coauthor := range coauthors {
+		if keep[i] {
+			kept = append(kept, coauthor)
+		}
+	}
+
+	return kept, nil
+}
+
+func describeCoauthors(coauthors []config.Collaborator) string {
+	var names []string
+	for _, coauthor := range coauthors {
+		names = append(names, coauthor.Alias)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// confirmPairing asks on the terminal whether the effective pairing is still going, if gpair.confirm calls for it.
+// It returns false if the user has stopped pairing, which only stops it in the current repo if the pairing is
+// for its directory or global. Without a terminal, e.g. in CI or GUI clients, it never asks.
+func confirmPairing() (bool, error) {
+	setting, err := git.GetConfirm()
+	if err != nil || setting == "" {
+		return true, err
+	}
+
+	global, restore, err := enterEffectiveScope()
+	if err != nil {
+		return true, err
+	}
+	defer restore()
+
+	key := getSessionKey(global)
+	sessions, err := session.NewManager(key)
+	if err != nil {
+		return true, err
+	}
+
+	current, err := sessions.Get()
+	if err != nil || current.IsEmpty() {
+		return true, err
+	}
+
+	now := time.Now()
+	ask, err := needsConfirmation(current, setting, now)
+	if err != nil || !ask {
+		return true, err
+	}
+
+	tty, err := openTerminal()
+	if err != nil {
+		internal.PrintVerbose("No terminal to ask whether you are still pairing, skipping")
+		return true, nil
+	}
+	defer tty.Close()
+
+	kept, err := promptConfirmation(bufio.NewReader(tty), tty, current.GetCoauthors())
+	if err != nil {
+		return true, err
+	}
+
+	if len(kept) == 0 && (global || dirScope != "") {
+		// Answering in one repo must not stop pairing in every other repo of the scope
+		restore()
+		err = git.SetNoPairing()
+		if err != nil {
+			return true, err
+		}
+
+		recordEvent(history.Solo, nil, session.Session{}, false)
+		fmt.Fprintln(tty, "Working solo in this repo, no co-authors added. Run 'gpair solo' in this repo to follow the pairing for other repos again.")
+
+		return false, nil
+	}
+
+	if len(kept) == 0 {
+		err = endSession(sessions, global)
+		if err != nil {
+			return true, err
+		}
+
+		recordEvent(history.Solo, nil, session.Session{}, global)
+		fmt.Fprintln(tty, "Working solo, no co-authors added.")
+
+		return false, nil
+	}
+
+	var left []string
+	for _, alias := range current.Aliases {
+		if _, ok := findAlias(kept, alias); !ok {
+			left = append(left, alias)
+		}
+	}
+
+	current.Remove(left...)
+	current.Confirmed = now
+
+	if len(left) == 0 {
+		return true, sessions.Save(current)
+	}
+
+	err = applySession(key, sessions, current, global)
+	if err != nil {
+		return true, err
+	}
+
+	recordEvent(history.Leave, left, current, global)
+
+	return true, nil
+}
+
+func findAlias(collaborators []config.Collaborator, alias string) (config.Collaborator, bool) {
+	for _, collaborator := range collaborators {
+		if collaborator.Alias == alias {
+			return collaborator, true
+		}
+	}
+
+	return config.Collaborator{}, false
+}
+
+// openTerminal opens the terminal to ask the user a question, failing if gpair is not allowed to ask any
+func openTerminal() (*os.File, error) {
+	if os.Getenv(noPromptEnv) != "" {
+		return nil, fmt.Errorf("not asking questions while running in several repos")
+	}
+
+	return os.OpenFile("/dev/tty", os.O_RDWR, 0)
+}
@@ -0,0 +1,81 @@
+package subcommands
+
+import (
+	"bufio"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+func TestNeedsConfirmation(t *testing.T) {
+	now := time.Date(2020, 6, 2, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		confirmed time.Time
+		setting   string
+		want      bool
+		wantErr   bool
+	}{
+		{"disabled", now.AddDate(0, 0, -3), time.Time{}, "false", false, false},
+		{"daily same day", now.Add(-time.Hour), time.Time{}, "daily", false, false},
+		{"daily new day", now.AddDate(0, 0, -1), time.Time{}, "daily", true, false},
+		{"daily confirmed today", now.AddDate(0, 0, -1), now.Add(-time.Hour), "daily", false, false},
+		{"threshold not reached", now.Add(-time.Hour), time.Time{}, "4h", false, false},
+		{"threshold reached", now.Add(-5 * time.Hour), time.Time{}, "4h", true, false},
+		{"threshold since confirmation", now.Add(-5 * time.Hour), now.Add(-time.Hour), "4h", false, false},
+		{"both", now.Add(-5 * time.Hour), time.Time{}, "daily, 4h", true, false},
+		{"invalid", now, time.Time{}, "sometimes", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			current := session.Session{Start: tt.start, Confirmed: tt.confirmed}
+			got, err := needsConfirmation(current, tt.setting, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("needsConfirmation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("needsConfirmation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptConfirmation(t *testing.T) {
+	a1 := config.NewCollaborator("a1", "name1", "email1")
+	a2 := config.NewCollaborator("a2", "name2", "email2")
+	a3 := config.NewCollaborator("a3", "name3", "email3")
+
+	tests := []struct {
+		name  string
+		input string
+		want  []config.Collaborator
+	}{
+		{"default yes", "\n", []config.Collaborator{a1, a2, a3}},
+		{"yes", "y\n", []config.Collaborator{a1, a2, a3}},
+		{"no input", "", []config.Collaborator{a1, a2, a3}},
+		{"no", "n\n", nil},
+		{"edit", "edit\n2\n\n", []config.Collaborator{a1, a3}},
+		{"edit several", "e\n1 3\n1\n\n", []config.Collaborator{a1, a2}},
+		{"edit invalid", "e\n7 x\n\n", []config.Collaborator{a1, a2, a3}},
+		{"edit everyone out", "e\n1 2 3\n", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := promptConfirmation(bufio.NewReader(strings.NewReader(tt.input)), ioutil.Discard, []config.Collaborator{a1, a2, a3})
+			if err != nil {
+				t.Fatalf("promptConfirmation() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("promptConfirmation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// AmendCmd is the flagset for the 'amend' subcommand
+var AmendCmd flag.FlagSet
+
+// CreditCmd is the flagset for the 'credit' subcommand
+var CreditCmd flag.FlagSet
+
+var forceMode bool
+
+func init() {
+	AmendCmd = *flag.NewFlagSet("amend", flag.ExitOnError)
+	CreditCmd = *flag.NewFlagSet("credit", flag.ExitOnError)
+	for _, flags := range []*flag.FlagSet{&AmendCmd, &CreditCmd} {
+		flags.BoolVar(&internal.Help, "help", false, "Display usage information")
+		flags.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+		flags.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+		flags.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+		flags.BoolVar(&forceMode, "force", false, "\nRewrite commits even if they have already been pushed")
+		flags.StringVar(&trailerKey, "trailer", "", "Credit the alias right after this flag with this trailer instead of Co-authored-by, e.g. 'Reviewed-by'")
+		flags.BoolVar(&dcoMode, "dco", false, "\nCredit each coauthor with Co-developed-by and Signed-off-by, with the committer signing off last")
+	}
+
+	oldAmendUsage := AmendCmd.Usage
+	AmendCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'amend' subcommand adds the trailers of the collaborators with the given aliases to the last commit,")
+		fmt.Println("for when you forgot to pair before committing: 'gpair amend ALIAS_1 [ALIAS_2 ...]'.")
+		fmt.Println("The author, committer and dates of the commit are kept. A commit that has been pushed is left alone")
+		fmt.Println("unless --force is given.")
+		fmt.Println()
+		oldAmendUsage()
+		AmendCmd.PrintDefaults()
+		fmt.Println()
+	}
+
+	oldCreditUsage := CreditCmd.Usage
+	CreditCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'credit' subcommand adds the trailers of the collaborators with the given aliases to every commit in")
+		fmt.Println("a range of the current branch, such as 'gpair credit origin/main..HEAD ALIAS_1 [ALIAS_2 ...]'.")
+		fmt.Println("The commits after the range are rewritten too, keeping their messages. The author, committer and dates")
+		fmt.Println("of every commit are kept, but signatures are dropped. gpair prints the new SHA of each rewritten commit.")
+		fmt.Println("Commits that have been pushed to the upstream branch are left alone unless --force is given.")
+		fmt.Println()
+		oldCreditUsage()
+		CreditCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// Amend is the function executed by the 'amend' subcommand
+// It adds the trailers of the given collaborators to the last commit
+func Amend() {
+	aliases, trailers, err := parseAliasArgs(&AmendCmd, os.Args[2:])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(0)
+	}
+
+	if internal.Help || len(aliases) == 0 {
+		AmendCmd.Usage()
+		os.Exit(0)
+	}
+
+	credit("HEAD^!", aliases, trailers, "gpair amend")
+}
+
+// Credit is the function executed by the 'credit' subcommand
+// It adds the trailers of the given collaborators to every commit in a range of the current branch
+func Credit() {
+	args, trailers, err := parseAliasArgs(&CreditCmd, os.Args[2:])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(0)
+	}
+
+	if internal.Help || len(args) < 2 {
+		CreditCmd.Usage()
+		os.Exit(0)
+	}
+
+	credit(args[0], args[1:], trailers, "gpair credit")
+}
+
+// credit adds the trailers of the collaborators with the given aliases to the commits in revRange,
+// rewriting the current branch, and prints the SHA of each rewritten commit
+func credit(revRange string, aliases []string, trailers map[string]string, reason string) {
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	_, err := git.GetRepoRoot()
+	if err != nil {
+		fmt.Println("gpair must be run inside a git repository to credit commits")
+		os.Exit(0)
+	}
+
+	configurator, err := config.NewConfigurator()
+	if err != nil {
+		panic(err)
+	}
+
+	collaborators, err := configurator.GetCollaborators(aliases...)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(0)
+	}
+
+	collaborators, err = setTrailers(collaborators, trailers)
+	if err != nil {
+		panic(err)
+	}
+
+	style, err := getTrailerStyle()
+	if err != nil {
+		panic(err)
+	}
+
+	operation, err := git.GetOperationInProgress()
+	if err != nil {
+		panic(err)
+	}
+
+	if operation != "" {
+		fmt.Printf("A %s is in progress. Finish or abort it first.\n", operation)
+		os.Exit(0)
+	}
+
+	rewritten, err := creditCommits(revRange, style, collaborators, forceMode, reason)
+	if err != nil {
+		if err, ok := err.(*git.ErrRevisionRange); ok {
+			fmt.Println(err.Error())
+			os.Exit(0)
+		}
+
+		if err, ok := err.(*errPushed); ok {
+			fmt.Println(err.Error())
+			fmt.Println("Run again with --force to rewrite it anyway, then force-push.")
+			os.Exit(0)
+		}
+
+		panic(err)
+	}
+
+	if len(rewritten) == 0 {
+		fmt.Println("The commits already credit these collaborators, so nothing was rewritten.")
+		return
+	}
+
+	var signed []string
+	for _, commit := range rewritten {
+		fmt.Printf("%s -> %s %s\n", shortSHA(commit.oldSHA), shortSHA(commit.newSHA), commit.subject)
+		if commit.signed {
+			signed = append(signed, shortSHA(commit.oldSHA))
+		}
+	}
+
+	if len(signed) > 0 {
+		fmt.Printf("\nWarning: the signatures of %s were dropped. Sign the commits again with\n", strings.Join(signed, ", "))
+		fmt.Println("'git rebase --exec \"git commit --amend --no-edit -S\"' if your project requires signed commits.")
+	}
+}
+
+// errPushed is returned when gpair would rewrite a commit that has already been pushed
+type errPushed struct {
+	sha string
+}
+
+func (err *errPushed) Error() string {
+	return fmt.Sprintf("Commit %s has already been pushed, so gpair will not rewrite it.", shortSHA(err.sha))
+}
+
+// creditCommits adds the trailers of the collaborators to the commits in revRange, rewriting the commits after them
+// and pointing the current branch at the result, and returns the rewritten commits.
+// It returns an errPushed if a commit in the range has already been pushed, unless force is true.
+func creditCommits(revRange, style string, collaborators []config.Collaborator, force bool, reason string) ([]rewrittenCommit, error) {
+	targets, rewrites, err := getRewrites(revRange)
+	if err != nil {
+		return nil, err
+	}
+
+	if !force {
+		for _, sha := range rewrites {
+			if !targets[sha] {
+				continue
+			}
+
+			pushed, err := git.IsPushed(sha)
+			if err != nil {
+				return nil, err
+			}
+
+			if pushed {
+				return nil, &errPushed{sha}
+			}
+		}
+	}
+
+	rewritten, err := rewriteCommits(rewrites, func(commit git.RawCommit) string {
+		if !targets[commit.SHA] {
+			return commit.Message
+		}
+
+		return creditMessage(commit, style, collaborators)
+	})
+	if err != nil || len(rewritten) == 0 {
+		return rewritten, err
+	}
+
+	// Every rewritten commit is an ancestor of HEAD, so HEAD is always rewritten last
+	head := rewritten[len(rewritten)-1]
+
+	return rewritten, git.UpdateHead(head.newSHA, head.oldSHA, reason)
+}
+
+// getRewrites returns the commits in revRange, and every commit of the current branch that has to be rewritten
+// when they are, in the order they have to be rewritten: the commits in the range and those after them
+func getRewrites(revRange string) (map[string]bool, []string, error) {
+	shas, err := git.RevList(revRange)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(shas) == 0 {
+		return nil, nil, &git.ErrRevisionRange{Range: revRange, Reason: "there are no commits in it"}
+	}
+
+	targets := make(map[string]bool)
+	for _, sha := range shas {
+		targets[sha] = true
+	}
+
+	// The commits just before the range are not rewritten, nor is anything before them
+	boundary, err := git.RevList("--boundary", revRange)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	args := []string{"--reverse", "--topo-order", "HEAD", "--not"}
+	for _, sha := range boundary {
+		if strings.HasPrefix(sha, "-") {
+			args = append(args, strings.TrimPrefix(sha, "-"))
+		}
+	}
+
+	rewrites, err := git.RevList(args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	onBranch := make(map[string]bool)
+	for _, sha := range rewrites {
+		onBranch[sha] = true
+	}
+
+	for _, sha := range shas {
+		if !onBranch[sha] {
+			return nil, nil, &git.ErrRevisionRange{Range: revRange, Reason: fmt.Sprintf("commit %s is not on the current branch", shortSHA(sha))}
+		}
+	}
+
+	return targets, rewrites, nil
+}
+
+// rewrittenCommit is a commit that was copied with a new message or new parents
+type rewrittenCommit struct {
+	oldSHA  string
+	newSHA  string
+	subject string
+	signed  bool
+}
+
+// rewriteCommits copies the given commits, parents first, with the message returned by edit and their rewritten parents.
+// A commit whose message and parents are unchanged is kept as it is. The rewritten commits are returned in order.
+func rewriteCommits(shas []string, edit func(commit git.RawCommit) string) ([]rewrittenCommit, error) {
+	newSHAs := make(map[string]string)
+	var rewritten []rewrittenCommit
+	for _, sha := range shas {
+		commit, err := git.ReadCommit(sha)
+		if err != nil {
+			return nil, err
+		}
+
+		changed := false
+		var parents []string
+		for _, parent := range commit.Parents {
+			if newParent, ok := newSHAs[parent]; ok && newParent != parent {
+				parent = newParent
+				changed = true
+			}
+			parents = append(parents, parent)
+		}
+
+		message := edit(commit)
+		if !changed && message == commit.Message {
+			newSHAs[sha] = sha
+			continue
+		}
+
+		newSHA, err := commit.Write(parents, message)
+		if err != nil {
+			return nil, err
+		}
+
+		newSHAs[sha] = newSHA
+		rewritten = append(rewritten, rewrittenCommit{sha, newSHA, commit.Subject(), commit.Signed})
+	}
+
+	return rewritten, nil
+}
+
+// creditMessage returns the message of a commit with the trailers of the collaborators added,
+// leaving out its author, and with its committer signing off last in DCOStyle
+func creditMessage(commit git.RawCommit, style string, collaborators []config.Collaborator) string {
+	author := commit.GetAuthor()
+
+	var coauthors []config.Collaborator
+	for _, collaborator := range collaborators {
+		if !strings.EqualFold(collaborator.Email, author.Email) {
+			coauthors = append(coauthors, collaborator)
+		}
+	}
+
+	if len(coauthors) == 0 {
+		return commit.Message
+	}
+
+	return git.AppendMessageTrailers(commit.Message, git.GetTrailers(style, commit.GetCommitter(), coauthors...))
+}
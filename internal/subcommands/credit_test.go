@@ -0,0 +1,203 @@
+package subcommands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+func TestCreditMessage(t *testing.T) {
+	alice := config.NewCollaborator("alice", "Alice", "alice@example.com")
+	bob := config.NewCollaborator("bob", "Bob", "bob@example.com")
+	commit := git.RawCommit{
+		Author:    "Alice <alice@example.com> 1600000000 +0000",
+		Committer: "Me <me@example.com> 1600000000 +0000",
+		Message:   "fix bug\n",
+	}
+
+	tests := []struct {
+		name          string
+		style         string
+		collaborators []config.Collaborator
+		want          string
+	}{
+		{"coauthor", git.DefaultStyle, []config.Collaborator{bob}, "fix bug\n\nCo-authored-by: Bob <bob@example.com>\n"},
+		{"author left out", git.DefaultStyle, []config.Collaborator{alice, bob}, "fix bug\n\nCo-authored-by: Bob <bob@example.com>\n"},
+		{"only the author", git.DefaultStyle, []config.Collaborator{alice}, "fix bug\n"},
+		{
+			"committer signs off last",
+			git.DCOStyle,
+			[]config.Collaborator{bob},
+			"fix bug\n\nCo-developed-by: Bob <bob@example.com>\nSigned-off-by: Bob <bob@example.com>\nSigned-off-by: Me <me@example.com>\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := creditMessage(commit, tt.style, tt.collaborators); got != tt.want {
+				t.Errorf("creditMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// makeCreditHistory commits c1 to c4 on the current branch, and s1 on a side branch off c1
+func makeCreditHistory(t *testing.T) (c1, c2, c3, c4, s1 string) {
+	t.Helper()
+
+	c1 = commitFile(t, "a.txt", "1", "first")
+	runGit(t, "checkout", "-q", "-b", "side")
+	s1 = commitFile(t, "b.txt", "side", "side")
+	runGit(t, "checkout", "-q", "-")
+	c2 = commitFile(t, "a.txt", "2", "second")
+	c3 = commitFile(t, "a.txt", "3", "third")
+	c4 = commitFile(t, "a.txt", "4", "fourth")
+
+	return c1, c2, c3, c4, s1
+}
+
+func Test_getRewrites(t *testing.T) {
+	_, cleanup := enterTestRepo(t)
+	defer cleanup()
+
+	c1, c2, c3, c4, s1 := makeCreditHistory(t)
+
+	tests := []struct {
+		name         string
+		revRange     string
+		wantTargets  []string
+		wantRewrites []string
+		wantErr      bool
+	}{
+		{"range and the commits after it", c1 + ".." + c3, []string{c2, c3}, []string{c2, c3, c4}, false},
+		{"last commit", "HEAD^!", []string{c4}, []string{c4}, false},
+		{"whole branch", "HEAD", []string{c1, c2, c3, c4}, []string{c1, c2, c3, c4}, false},
+		{"empty range", "HEAD..HEAD", nil, nil, true},
+		{"commit on another branch", c1 + ".." + s1, nil, nil, true},
+		{"unknown revision", "nope..HEAD", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets, rewrites, err := getRewrites(tt.revRange)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getRewrites() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if _, ok := err.(*git.ErrRevisionRange); !ok {
+					t.Errorf("getRewrites() error = %T, want *git.ErrRevisionRange", err)
+				}
+				return
+			}
+
+			if len(targets) != len(tt.wantTargets) {
+				t.Errorf("getRewrites() targets = %v, want %v", targets, tt.wantTargets)
+			}
+			for _, sha := range tt.wantTargets {
+				if !targets[sha] {
+					t.Errorf("getRewrites() targets = %v, want %v", targets, tt.wantTargets)
+				}
+			}
+			if !reflect.DeepEqual(rewrites, tt.wantRewrites) {
+				t.Errorf("getRewrites() rewrites = %v, want %v", rewrites, tt.wantRewrites)
+			}
+		})
+	}
+}
+
+func Test_creditCommits(t *testing.T) {
+	bob := config.NewCollaborator("bob", "Bob", "bob@example.com")
+	me := config.NewCollaborator("me", "Me", "me@example.com")
+	credited := "\n\nCo-authored-by: Bob <bob@example.com>\n"
+
+	tests := []struct {
+		name          string
+		collaborators []config.Collaborator
+		pushed        int
+		force         bool
+		// The range is from..to, and the other fields index the commits c1 to c4 from 0 too
+		from       int
+		to         int
+		wantOld    []int
+		wantPushed int
+	}{
+		{"range and the commits after it", []config.Collaborator{bob}, -1, false, 1, 2, []int{2, 3}, -1},
+		{"refuses a pushed commit", []config.Collaborator{bob}, 1, false, 0, 3, nil, 1},
+		{"commits before the range are pushed", []config.Collaborator{bob}, 1, false, 1, 3, []int{2, 3}, -1},
+		{"forced", []config.Collaborator{bob}, 1, true, 0, 3, []int{1, 2, 3}, -1},
+		{"nothing to credit", []config.Collaborator{me}, -1, false, 0, 3, nil, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := enterTestRepo(t)
+			defer cleanup()
+
+			c1, c2, c3, c4, _ := makeCreditHistory(t)
+			commits := []string{c1, c2, c3, c4}
+			logFormat := "--format=%T %an <%ae> %ad %cn <%ce> %cd"
+			oldLog := runGit(t, "log", logFormat)
+
+			if tt.pushed >= 0 {
+				runGit(t, "update-ref", "refs/remotes/origin/master", commits[tt.pushed])
+			}
+
+			rewritten, err := creditCommits(commits[tt.from]+".."+commits[tt.to], git.DefaultStyle, tt.collaborators, tt.force, "test")
+			if tt.wantPushed >= 0 {
+				if pushed, ok := err.(*errPushed); !ok || pushed.sha != commits[tt.wantPushed] {
+					t.Fatalf("creditCommits() error = %v, want commit %s pushed", err, commits[tt.wantPushed])
+				}
+			} else if err != nil {
+				t.Fatalf("creditCommits() error = %v", err)
+			}
+
+			head := strings.TrimSpace(runGit(t, "rev-parse", "HEAD"))
+			if len(tt.wantOld) == 0 {
+				if len(rewritten) != 0 || head != c4 {
+					t.Errorf("creditCommits() rewrote %v and moved HEAD to %s, want nothing rewritten", rewritten, head)
+				}
+				return
+			}
+
+			var gotOld []string
+			for _, commit := range rewritten {
+				gotOld = append(gotOld, commit.oldSHA)
+			}
+			var wantOld []string
+			for _, i := range tt.wantOld {
+				wantOld = append(wantOld, commits[i])
+			}
+			if !reflect.DeepEqual(gotOld, wantOld) {
+				t.Fatalf("creditCommits() rewrote %v, want %v", gotOld, wantOld)
+			}
+
+			if last := rewritten[len(rewritten)-1]; head != last.newSHA {
+				t.Errorf("HEAD = %s, want the rewritten %s", head, last.newSHA)
+			}
+
+			// Each rewritten commit has the rewritten commit before it as its parent
+			parent := strings.TrimSpace(runGit(t, "rev-parse", commits[tt.wantOld[0]]+"^"))
+			for i, commit := range rewritten {
+				if got := strings.TrimSpace(runGit(t, "rev-parse", commit.newSHA+"^")); got != parent {
+					t.Errorf("parent of %s = %s, want %s", commit.subject, got, parent)
+				}
+				parent = commit.newSHA
+
+				oldMessage := runGit(t, "log", "-1", "--format=%B", commit.oldSHA)
+				wantMessage := oldMessage
+				// Only the commits in the range are credited, and the commits after it are only rewritten
+				if tt.wantOld[i] <= tt.to {
+					wantMessage = strings.TrimSpace(oldMessage) + credited
+				}
+				if got := runGit(t, "log", "-1", "--format=%B", commit.newSHA); strings.TrimSpace(got) != strings.TrimSpace(wantMessage) {
+					t.Errorf("message of %s = %q, want %q", commit.subject, got, wantMessage)
+				}
+			}
+
+			// Trees, authorship and dates are kept
+			if newLog := runGit(t, "log", logFormat); newLog != oldLog {
+				t.Errorf("creditCommits() changed the log from\n%s\nto\n%s", oldLog, newLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package subcommands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// dirPath is the value of the --dir flag, which pairs for every repo in a directory
+var dirPath string
+
+// dirScope is the absolute path of the directory whose pairing gpair is acting on, or "" if it is not acting on one
+var dirScope string
+
+// resolveDir returns the absolute path of the directory given to --dir, expanding a leading "~/"
+func resolveDir(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+
+	return filepath.Abs(path)
+}
+
+// enterDirScope makes per-repo operations act on the pairing for every repo in dir instead.
+// It returns a function that switches back.
+func enterDirScope(dir string) (func(), error) {
+	configPath, err := git.GetDirConfigPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// git creates the config file when writing to it, but not its directory
+	err = os.MkdirAll(filepath.Dir(configPath), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	restoreConfig := git.UseConfigFile(configPath)
+	previous := dirScope
+	dirScope = dir
+
+	return func() {
+		restoreConfig()
+		dirScope = previous
+	}, nil
+}
+
+// useDirFlag switches to the directory given with --dir, if any. It exits if --dir is combined with --global.
+func useDirFlag(global bool) error {
+	if dirPath == "" {
+		return nil
+	}
+
+	if global {
+		fmt.Println("--dir and --global cannot be used together")
+		exitWithError()
+	}
+
+	dir, err := resolveDir(dirPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = enterDirScope(dir)
+
+	return err
+}
+
+// enterEffectiveScope switches to the scope of the pairing git uses in the current repo,
+// and returns whether it is the global one along with a function that switches back
+func enterEffectiveScope() (bool, func(), error) {
+	noop := func() {}
+
+	localPairing, err := git.GetScopedPairing(false)
+	if err != nil || localPairing != "" {
+		return false, noop, err
+	}
+
+	effectivePairing, err := git.GetPairing()
+	if err != nil || effectivePairing == "" {
+		return true, noop, err
+	}
+
+	pairings, err := git.GetDirPairings()
+	if err != nil {
+		return true, noop, err
+	}
+
+	for _, pairing := range pairings {
+		restore, err := enterDirScope(pairing.Dir)
+		if err != nil {
+			return true, noop, err
+		}
+
+		dirPairing, err := git.GetScopedPairing(false)
+		if err == nil && dirPairing == effectivePairing {
+			return false, restore, nil
+		}
+		restore()
+	}
+
+	return true, noop, nil
+}
+
+// includeDirPairings includes the pairing for the current directory in the global git config, after pairing for it.
+// After pairing globally, every pairing for a directory is included again, so that it still takes precedence.
+func includeDirPairings(global bool) error {
+	var pairings []git.DirPairing
+	if global {
+		var err error
+		pairings, err = git.GetDirPairings()
+		if err != nil {
+			return err
+		}
+	} else if dirScope != "" {
+		configPath, err := git.GetDirConfigPath(dirScope)
+		if err != nil {
+			return err
+		}
+
+		pairings = append(pairings, git.DirPairing{Dir: dirScope, ConfigPath: configPath})
+	}
+
+	for _, pairing := range pairings {
+		err := git.AddDirInclude(pairing.Dir, pairing.ConfigPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package subcommands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_resolveDir(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"absolute", "/work/acme/", "/work/acme"},
+		{"home", "~/work/acme", filepath.Join(home, "work", "acme")},
+		{"relative", "acme", filepath.Join(wd, "acme")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDir(tt.path)
+			if err != nil {
+				t.Fatalf("resolveDir() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveDir() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
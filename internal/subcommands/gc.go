@@ -0,0 +1,386 @@
+package subcommands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/registry"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// GcCmd is the flagset for the 'gc' subcommand
+var GcCmd flag.FlagSet
+
+var dryRunMode bool
+
+var legacyMode bool
+
+// repoKeyPattern matches the end of the key of a repo, as opposed to the name alone that older versions of gpair used
+var repoKeyPattern = regexp.MustCompile(`-[0-9a-f]{12}$`)
+
+func init() {
+	GcCmd = *flag.NewFlagSet("gc", flag.ExitOnError)
+	GcCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	GcCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	GcCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	GcCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	GcCmd.BoolVar(&dryRunMode, "dry-run", false, "\nOnly list the files that would be removed")
+	GcCmd.BoolVar(&legacyMode, "legacy", false, "\nAlso remove the files of repos paired with an older version of gpair that no known git config points at")
+	oldUsage := GcCmd.Usage
+	GcCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'gc' subcommand removes the templates, sessions and backups in ~/.gpair that nothing uses any more,")
+		fmt.Println("such as those of repos that have been deleted.")
+		fmt.Println("A file is kept if git config points at it, or if it belongs to the global scope, a directory pairing,")
+		fmt.Println("a repo listed by 'gpair sessions' or a repo in 'gpair history' that still exists.")
+		fmt.Println("The templates of repos paired with an older version of gpair are named after the repo alone, so gpair")
+		fmt.Println("cannot tell whether a repo it has not seen since still uses them. They are only removed with --legacy.")
+		fmt.Println("Run it with --dry-run first to see what it would remove.")
+		fmt.Println()
+		oldUsage()
+		GcCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// gcState is what gpair knows about which scopes still use their files
+type gcState struct {
+	sessionsDir string
+	// live holds the keys of scopes that still exist
+	live map[string]bool
+	// dead holds the keys of repos that have been deleted
+	dead map[string]bool
+	// referenced holds the files that git config points at
+	referenced map[string]bool
+}
+
+// isLive returns true if the files saved under key are still used.
+// A repo that gpair knows nothing about is kept while its session has coauthors, since it may still be pairing.
+func (s gcState) isLive(key string) bool {
+	if s.live[key] {
+		return true
+	}
+
+	if s.dead[key] || !repoKeyPattern.MatchString(key) {
+		return false
+	}
+
+	sessionBytes, err := ioutil.ReadFile(filepath.Join(s.sessionsDir, key+".json"))
+	if err != nil {
+		return false
+	}
+
+	var current session.Session
+	err = json.Unmarshal(sessionBytes, &current)
+
+	return err == nil && !current.IsEmpty()
+}
+
+// Gc is the function executed by the 'gc' subcommand
+// It removes the files in ~/.gpair that are no longer used
+func Gc() {
+	err := GcCmd.Parse(os.Args[2:])
+	if err != nil || internal.Help {
+		GcCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		panic(err)
+	}
+	gpairDir := filepath.Join(home, ".gpair")
+
+	reg, err := registry.NewRegistry()
+	if err != nil {
+		panic(err)
+	}
+
+	state, deletedEntries, err := getGcState(gpairDir, reg)
+	if err != nil {
+		panic(err)
+	}
+
+	orphans, legacy, err := findOrphans(gpairDir, state.isLive, state.referenced)
+	if err != nil {
+		panic(err)
+	}
+
+	if legacyMode {
+		orphans = append(orphans, legacy...)
+	} else if len(legacy) > 0 {
+		fmt.Println("Kept these files of repos paired with an older version of gpair, since a repo gpair has not seen")
+		fmt.Println("since may still use them:")
+		for _, path := range legacy {
+			fmt.Printf("  %s\n", path)
+		}
+		fmt.Println("If you no longer pair in those repos, run 'gpair gc --legacy' to remove them too.")
+		fmt.Println()
+	}
+
+	verb := "Removed"
+	if dryRunMode {
+		verb = "Would remove"
+	}
+
+	var reclaimed int64
+	for _, orphan := range orphans {
+		stats, err := os.Stat(orphan)
+		if err != nil {
+			continue
+		}
+
+		if !dryRunMode {
+			err = os.Remove(orphan)
+			if err != nil {
+				fmt.Printf("Failed to remove %s: %v\n", orphan, err)
+				continue
+			}
+		}
+
+		fmt.Printf("%s %s\n", verb, orphan)
+		reclaimed += stats.Size()
+	}
+
+	if !dryRunMode {
+		for _, entry := range deletedEntries {
+			err = reg.Remove(entry.Key, entry.Path)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		err = reg.Compact()
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if len(orphans) == 0 {
+		fmt.Println("Nothing to clean up")
+		return
+	}
+
+	if dryRunMode {
+		fmt.Printf("\nWould reclaim %d files (%d bytes). Run it again without --dry-run to remove them.\n", len(orphans), reclaimed)
+	} else {
+		fmt.Printf("\nReclaimed %d files (%d bytes)\n", len(orphans), reclaimed)
+	}
+}
+
+// getGcState finds the scopes that still use their files, by looking at the global git config, the directory pairings,
+// and the repos in the registry and the history journal. It also returns the registry entries of deleted repos.
+func getGcState(gpairDir string, reg registry.Registry) (gcState, []registry.Entry, error) {
+	state := gcState{
+		sessionsDir: filepath.Join(gpairDir, "sessions"),
+		live:        map[string]bool{getSessionKey(true): true},
+		dead:        make(map[string]bool),
+		referenced:  make(map[string]bool),
+	}
+
+	err := addReferencedFiles(state)
+	if err != nil {
+		return state, nil, err
+	}
+
+	pairings, err := git.GetDirPairings()
+	if err != nil {
+		return state, nil, err
+	}
+
+	for _, pairing := range pairings {
+		state.live[git.GetDirKey(pairing.Dir)] = true
+		state.referenced[pairing.ConfigPath] = true
+
+		restore, err := enterDirScope(pairing.Dir)
+		if err != nil {
+			return state, nil, err
+		}
+
+		err = addReferencedFiles(state)
+		restore()
+		if err != nil {
+			return state, nil, err
+		}
+	}
+
+	entries, err := reg.List()
+	if err != nil {
+		return state, nil, err
+	}
+
+	var deletedEntries []registry.Entry
+	var repos []string
+	for _, entry := range entries {
+		if entry.Scope == registry.Dir {
+			// The include is gone if the directory is not among the pairings, so the files are no longer used
+			if !state.live[entry.Key] {
+				deletedEntries = append(deletedEntries, entry)
+			}
+			continue
+		}
+
+		if isDeleted(entry) {
+			state.dead[entry.Key] = true
+			deletedEntries = append(deletedEntries, entry)
+			continue
+		}
+
+		state.live[entry.Key] = true
+		if entry.Path != "" {
+			repos = append(repos, entry.Path)
+		}
+	}
+
+	journal, err := history.NewJournal()
+	if err != nil {
+		return state, nil, err
+	}
+
+	events, err := journal.Query(history.Filter{})
+	if err != nil {
+		return state, nil, err
+	}
+
+	for _, event := range events {
+		if event.Repo != "" && event.Scope != registry.Dir {
+			repos = append(repos, event.Repo)
+		}
+	}
+
+	checked := make(map[string]bool)
+	for _, repo := range repos {
+		if checked[repo] {
+			continue
+		}
+		checked[repo] = true
+
+		if git.CheckRepo(repo) != nil {
+			// Only the key of a repo identified by its path can be known once it is gone
+			internal.PrintVerbose("%s is no longer a git repository", repo)
+			state.dead[git.HashRepoKey(repo, repo)] = true
+			continue
+		}
+
+		err = inRepo(repo, func() error {
+			key, err := git.GetRepoKey()
+			if err != nil {
+				return err
+			}
+			state.live[key] = true
+
+			return addReferencedFiles(state)
+		})
+		if err != nil {
+			return state, nil, err
+		}
+	}
+
+	return state, deletedEntries, nil
+}
+
+// addReferencedFiles adds the gpair files that the git config of the current scope points at to the state
+func addReferencedFiles(state gcState) error {
+	files, err := git.GetGpairFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		state.referenced[file] = true
+	}
+
+	return nil
+}
+
+// inRepo runs fn with the working directory changed to repo
+func inRepo(repo string, fn func() error) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chdir(repo)
+	if err != nil {
+		return err
+	}
+	defer os.Chdir(wd)
+
+	return fn()
+}
+
+// findOrphans returns the files in gpairDir that no scope uses: templates, sessions and directory configs
+// whose key is not live and that no git config points at, and backups of files that are gone.
+// The files of repos paired before repos had keys are returned separately, since any repo might still use them.
+// Other files, such as config.json and the hooks directory, are left alone.
+func findOrphans(gpairDir string, isLive func(key string) bool, referenced map[string]bool) ([]string, []string, error) {
+	kinds := map[string][]string{
+		gpairDir:                            {"-template.txt"},
+		filepath.Join(gpairDir, "sessions"): {".branches.json", ".last.json", ".json"},
+		filepath.Join(gpairDir, "dirs"):     {".gitconfig"},
+	}
+
+	var orphans, legacy []string
+	for _, dir := range []string{gpairDir, filepath.Join(gpairDir, "sessions"), filepath.Join(gpairDir, "dirs")} {
+		infos, err := ioutil.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		for _, info := range infos {
+			if info.IsDir() {
+				continue
+			}
+
+			path := filepath.Join(dir, info.Name())
+			original := strings.TrimSuffix(path, ".bak")
+
+			key, ok := getStateKey(filepath.Base(original), kinds[dir])
+			if !ok {
+				if _, err := os.Stat(original); original != path && os.IsNotExist(err) {
+					orphans = append(orphans, path)
+				}
+				continue
+			}
+
+			if referenced[original] || isLive(key) {
+				continue
+			}
+
+			if repoKeyPattern.MatchString(key) {
+				orphans = append(orphans, path)
+			} else {
+				legacy = append(legacy, path)
+			}
+		}
+	}
+
+	return orphans, legacy, nil
+}
+
+// getStateKey returns the key of the scope a file named filename belongs to, if it has one of the given suffixes
+func getStateKey(filename string, suffixes []string) (string, bool) {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(filename, suffix) && filename != suffix {
+			return strings.TrimSuffix(filename, suffix), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,84 @@
+package subcommands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func Test_findOrphans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpair_gc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{
+		"config.json",
+		"config.json.bak",
+		"history.jsonl.bak",
+		"gpair-global-template.txt",
+		"api-0123456789ab-template.txt",
+		"api-0123456789ab-template.txt.bak",
+		"gone-0123456789ab-template.txt",
+		"gone-0123456789ab-template.txt.bak",
+		"api-template.txt",
+		"billing-template.txt",
+		"hooks/prepare-commit-msg",
+		"sessions/api-0123456789ab.json",
+		"sessions/gone-0123456789ab.json",
+		"sessions/gone-0123456789ab.last.json",
+		"sessions/gone-0123456789ab.branches.json.bak",
+		"dirs/dir-acme-0123456789ab.gitconfig",
+		"dirs/dir-old-0123456789ab.gitconfig",
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	live := map[string]bool{"gpair-global": true, "api-0123456789ab": true}
+	referenced := map[string]bool{
+		// A repo paired with an older version of gpair that still points at its template
+		filepath.Join(dir, "billing-template.txt"):                    true,
+		filepath.Join(dir, "dirs", "dir-acme-0123456789ab.gitconfig"): true,
+	}
+
+	got, legacy, err := findOrphans(dir, func(key string) bool { return live[key] }, referenced)
+	if err != nil {
+		t.Fatalf("findOrphans() error = %v", err)
+	}
+
+	var want []string
+	for _, file := range []string{
+		"history.jsonl.bak",
+		"gone-0123456789ab-template.txt",
+		"gone-0123456789ab-template.txt.bak",
+		"sessions/gone-0123456789ab.json",
+		"sessions/gone-0123456789ab.last.json",
+		"sessions/gone-0123456789ab.branches.json.bak",
+		"dirs/dir-old-0123456789ab.gitconfig",
+	} {
+		want = append(want, filepath.Join(dir, file))
+	}
+
+	sort.Strings(got)
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findOrphans() = %v, want %v", got, want)
+	}
+
+	// A template named after a repo alone may belong to a repo that gpair has not seen since it was paired
+	wantLegacy := []string{filepath.Join(dir, "api-template.txt")}
+	if !reflect.DeepEqual(legacy, wantLegacy) {
+		t.Errorf("findOrphans() legacy = %v, want %v", legacy, wantLegacy)
+	}
+}
@@ -0,0 +1,144 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// HistoryCmd is the flagset for the 'history' subcommand
+var HistoryCmd flag.FlagSet
+
+func init() {
+	HistoryCmd = *flag.NewFlagSet("history", flag.ExitOnError)
+	HistoryCmd.String("repo", "", "Only show pairings in the repo with this name or path. Use '.' for the current repo")
+	HistoryCmd.String("with", "", "Only show pairings with the collaborator with this alias")
+	HistoryCmd.String("since", "", "Only show pairings from this date on, as YYYY-MM-DD or a duration like '7d' or '12h'")
+	HistoryCmd.String("until", "", "Only show pairings before this date, as YYYY-MM-DD or a duration like '7d' or '12h'")
+	HistoryCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	HistoryCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	HistoryCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	HistoryCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	oldUsage := HistoryCmd.Usage
+	HistoryCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'history' subcommand shows who you have paired with, and where.")
+		fmt.Println("Every pair, join, leave and solo is recorded in ~/.gpair/history.jsonl.")
+		fmt.Println("For example, 'gpair history -repo billing -since 7d' shows last week's pairings in the billing repo.")
+		fmt.Println()
+		oldUsage()
+		HistoryCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+func parseHistoryArgs(args []string, now time.Time) (filter history.Filter, err error) {
+	err = HistoryCmd.Parse(args)
+	if err != nil {
+		return
+	}
+
+	filter.Repo = HistoryCmd.Lookup("repo").Value.String()
+	filter.Collaborator = HistoryCmd.Lookup("with").Value.String()
+
+	filter.Since, err = parseTime(HistoryCmd.Lookup("since").Value.String(), now)
+	if err != nil {
+		return
+	}
+
+	filter.Until, err = parseTime(HistoryCmd.Lookup("until").Value.String(), now)
+
+	return
+}
+
+// parseTime parses a date, a date and time, or a duration before now such as "7d" or "12h".
+// An empty value returns the zero time.
+func parseTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	if duration, err := session.ParseDuration(value); err == nil {
+		return now.Add(-duration), nil
+	}
+
+	return time.Time{}, fmt.Errorf("'%s' is not a date like YYYY-MM-DD or a duration like '7d'", value)
+}
+
+// History is the function executed by the 'history' subcommand
+// It prints the recorded pairing events that match the given filters
+func History() {
+	filter, err := parseHistoryArgs(os.Args[2:], time.Now())
+	if err != nil {
+		fmt.Println(err.Error())
+		HistoryCmd.Usage()
+		os.Exit(0)
+	}
+
+	if internal.Help {
+		HistoryCmd.Usage()
+		os.Exit(0)
+	}
+
+	if filter.Repo == "." {
+		filter.Repo, err = git.GetRepoRoot()
+		if err != nil {
+			fmt.Println("'-repo .' must be used inside a git repository")
+			os.Exit(0)
+		}
+	}
+
+	journal, err := history.NewJournal()
+	if err != nil {
+		panic(err)
+	}
+
+	events, err := journal.Query(filter)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(events) == 0 {
+		fmt.Println("No pairing history found")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0x0)
+	for _, event := range events {
+		where := event.Scope
+		if event.Repo != "" {
+			where = filepath.Base(event.Repo)
+		}
+
+		change := ""
+		switch event.Action {
+		case history.Join:
+			change = " (+" + strings.Join(event.Aliases, ", +") + ")"
+		case history.Leave:
+			change = " (-" + strings.Join(event.Aliases, ", -") + ")"
+		}
+
+		coauthors := strings.Join(event.Coauthors, ", ")
+		if coauthors == "" {
+			coauthors = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s%s\n", event.Time.Local().Format("2006-01-02 15:04"), event.Action, where, coauthors, change)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,36 @@
+package subcommands
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2020, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"empty", "", time.Time{}, false},
+		{"date", "2020-06-01", time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), false},
+		{"date and time", "2020-06-01 09:30", time.Date(2020, 6, 1, 9, 30, 0, 0, time.UTC), false},
+		{"days", "7d", time.Date(2020, 6, 3, 12, 0, 0, 0, time.UTC), false},
+		{"hours", "12h", time.Date(2020, 6, 10, 0, 0, 0, 0, time.UTC), false},
+		{"garbage", "last week", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTime(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseTime() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,498 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// HookCmd is the flagset for the 'hook' subcommand
+var HookCmd flag.FlagSet
+
+var hookName string
+
+var mentionsFlag bool
+
+var mentionsOnly bool
+
+func init() {
+	HookCmd = *flag.NewFlagSet("hook", flag.ExitOnError)
+	HookCmd.StringVar(&hookName, "hook", git.PrepareCommitMsgHook, "The git hook to install, either 'prepare-commit-msg' or 'commit-msg'")
+	HookCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	HookCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	HookCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	HookCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	HookCmd.BoolVar(&globalMode, "global", false, "\nInstall or uninstall hooks for all repos")
+	HookCmd.BoolVar(&globalMode, "g", false, "\nInstall or uninstall hooks for all repos (shorthand)")
+	HookCmd.BoolVar(&mentionsFlag, "mentions", false, "\nTurn on @alias mentions in commit messages, and install a commit-msg hook to expand them")
+	HookCmd.BoolVar(&mentionsOnly, "mentions-only", false, "\nOnly expand @alias mentions when run as a commit-msg hook, leaving the co-authors to prepare-commit-msg")
+	oldUsage := HookCmd.Usage
+	HookCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'hook' subcommand switches gpair to adding co-authors from a git hook instead of a commit template.")
+		fmt.Println("Unlike a commit template, the hook also works with 'git commit -m', merges and most IDEs.")
+		fmt.Println("Run 'gpair hook install' to install the hook in the current repo and switch it to the hook backend.")
+		fmt.Println("Run 'gpair hook uninstall' to remove the hook and switch back to the template backend.")
+		fmt.Println("With --global, the hook is installed in ~/.gpair/hooks and used by every repo through core.hooksPath.")
+		fmt.Println("With --mentions, '@alias' mentions in commit messages are expanded into trailers from a commit-msg hook,")
+		fmt.Println("which is installed too, since it sees the message after it is edited.")
+		fmt.Println("Existing hooks are kept and run before gpair. If a hook manager such as husky, lefthook or pre-commit")
+		fmt.Println("is in use, gpair prints the configuration to add instead of installing its own hook.")
+		fmt.Println()
+		oldUsage()
+		HookCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// parseHookArgs returns the hook action and its arguments, allowing flags both before and after the action
+func parseHookArgs(args []string) (action string, actionArgs []string, err error) {
+	err = HookCmd.Parse(args)
+	if err != nil || HookCmd.NArg() == 0 {
+		return
+	}
+
+	action = HookCmd.Arg(0)
+	err = HookCmd.Parse(HookCmd.Args()[1:])
+
+	return action, HookCmd.Args(), err
+}
+
+// Hook is the function executed by the 'hook' subcommand
+// It installs, uninstalls or runs the gpair commit message hook
+func Hook() {
+	action, args, err := parseHookArgs(os.Args[2:])
+	if err != nil {
+		panic(err)
+	}
+
+	if internal.Help || action == "" {
+		HookCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	switch action {
+	case "install":
+		err = installHook(hookName, globalMode)
+		if err == nil && mentionsFlag {
+			err = enableMentions(globalMode)
+		}
+
+	case "uninstall":
+		err = uninstallHooks(globalMode)
+
+	case "run":
+		if len(args) < 2 || !git.IsSupportedHook(args[0]) {
+			HookCmd.Usage()
+			os.Exit(0)
+		}
+
+		if args[0] == git.PostCheckoutHook {
+			// The third argument is 1 if a branch was checked out, and 0 if only files were
+			if len(args) >= 4 && args[3] == "1" {
+				err = followBranch()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "gpair: failed to switch to the pairing on this branch: %v\n", err)
+			}
+			os.Exit(0)
+		}
+
+		// Only the commit-msg hook sees the message as it was edited, so mentions are only expanded there
+		if args[0] == git.CommitMsgHook {
+			err = runMentions(args[1])
+			if eum, ok := err.(*errUnknownMention); ok {
+				// Abort the commit rather than commit without the credit that was asked for
+				fmt.Fprintf(os.Stderr, "gpair: %s\n", eum.Error())
+				fmt.Fprintln(os.Stderr, "gpair: fix the mention, add the collaborator with 'gpair add', or set gpair.mentions to 'off'.")
+				os.Exit(1)
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "gpair: failed to add the trailers of the mentioned collaborators: %v\n", err)
+			}
+		}
+
+		if mentionsOnly {
+			os.Exit(0)
+		}
+
+		err = runHook(args[1])
+		if err != nil {
+			// Never block a commit because the trailers could not be added
+			fmt.Fprintf(os.Stderr, "gpair: failed to add co-author trailers: %v\n", err)
+			os.Exit(0)
+		}
+
+	default:
+		fmt.Printf("Unknown hook action '%s'\n", action)
+		HookCmd.Usage()
+		os.Exit(0)
+	}
+
+	if err != nil {
+		if ehe, ok := err.(*git.ErrHookExists); ok {
+			fmt.Printf("A %s hook not managed by gpair already exists at %s, and an older one has already been moved aside.\n", hookName, ehe.Path)
+			fmt.Println(git.HookManagerSnippet("", hookName))
+			os.Exit(0)
+		}
+
+		panic(err)
+	}
+}
+
+func installHook(name string, global bool) error {
+	if !git.IsMessageHook(name) {
+		return fmt.Errorf("gpair cannot run as a '%s' hook", name)
+	}
+
+	gpairHooksDir, err := git.GetGlobalHooksDir()
+	if err != nil {
+		return err
+	}
+
+	globalHooksPath, err := git.GetHooksPath(true)
+	if err != nil {
+		return err
+	}
+
+	if global {
+		if globalHooksPath == "" || globalHooksPath == gpairHooksDir {
+			err = installGpairHooksDir(name, gpairHooksDir)
+			if err == nil {
+				err = installMentionsHook(name, gpairHooksDir, true)
+			}
+		} else {
+			// Respect the user's own global hooks directory rather than replacing it
+			_, err = git.InstallHook(globalHooksPath, name, false)
+			if err == nil {
+				internal.PrintVerbose("Installed %s hook in the global hooks directory %s", name, globalHooksPath)
+				err = installMentionsHook(name, globalHooksPath, false)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		return useHookBackend(name, true)
+	}
+
+	repoRoot, err := git.GetRepoRoot()
+	if err != nil {
+		fmt.Println("gpair must be run inside a git repository unless in global mode")
+		os.Exit(0)
+	}
+
+	localHooksPath, err := git.GetHooksPath(false)
+	if err != nil {
+		return err
+	}
+
+	if localHooksPath == "" && globalHooksPath == gpairHooksDir {
+		internal.PrintVerbose("The global gpair hooks in %s already run in this repo", gpairHooksDir)
+		err = installMentionsHook(name, gpairHooksDir, true)
+		if err != nil {
+			return err
+		}
+
+		return useHookBackend(name, false)
+	}
+
+	if localHooksPath == "" && globalHooksPath != "" {
+		fmt.Printf("core.hooksPath is set globally to %s, so hooks in this repo's .git/hooks will not run.\n", globalHooksPath)
+		fmt.Println("Run 'gpair hook install --global' to install the hook there instead.")
+		os.Exit(0)
+	}
+
+	hooksDir, err := git.GetHooksDir()
+	if err != nil {
+		return err
+	}
+
+	manager := git.DetectHookManager(repoRoot, hooksDir, name)
+	if manager != "" {
+		runsGpair := git.HookManagerRunsGpair(repoRoot, hooksDir, manager, name)
+		if !runsGpair {
+			fmt.Printf("The hooks in this repo are managed by %s, so gpair will not install its hook directly.\n", manager)
+			fmt.Println(git.HookManagerSnippet(manager, name))
+		}
+
+		mentions, err := needsMentionsHook(name)
+		if err != nil {
+			return err
+		}
+
+		if mentions && !git.HookManagerRunsMentions(repoRoot, hooksDir, manager) {
+			fmt.Println("To expand @alias mentions in commit messages, also add a commit-msg hook:")
+			fmt.Println(git.MentionsHookSnippet(manager))
+		}
+
+		if !runsGpair {
+			// Without the hook, switching backends would stop adding co-authors altogether
+			fmt.Println("Until then, gpair keeps adding co-authors with the commit template.")
+			fmt.Println("Once you have added it, run 'gpair hook install' again to switch to the hook.")
+			return nil
+		}
+
+		internal.PrintVerbose("%s already runs gpair as the %s hook", manager, name)
+		return useHookBackend(name, false)
+	}
+
+	hookPath, err := git.InstallHook(hooksDir, name, false)
+	if err != nil {
+		return err
+	}
+	internal.PrintVerbose("Installed %s hook at %s", name, hookPath)
+
+	err = installMentionsHook(name, hooksDir, false)
+	if err != nil {
+		return err
+	}
+
+	return useHookBackend(name, false)
+}
+
+// needsMentionsHook returns true if @alias mentions are turned on but the named hook cannot expand them,
+// because it runs before the commit message is edited
+func needsMentionsHook(name string) (bool, error) {
+	if name == git.CommitMsgHook {
+		return false, nil
+	}
+
+	if mentionsFlag {
+		return true, nil
+	}
+
+	mode, err := git.GetMentionsMode()
+	if err != nil {
+		return false, err
+	}
+
+	return mode != git.MentionsOff, nil
+}
+
+// enableMentions sets gpair.mentions to strip, unless mentions are already turned on
+func enableMentions(global bool) error {
+	mode, err := git.GetMentionsMode()
+	if err != nil || mode != git.MentionsOff {
+		return err
+	}
+
+	return git.SetMentionsMode(git.MentionsStrip, global)
+}
+
+// installMentionsHook installs a commit-msg hook that expands @alias mentions into hooksDir,
+// if mentions are turned on and the named hook cannot expand them itself
+func installMentionsHook(name, hooksDir string, chainLocal bool) error {
+	mentions, err := needsMentionsHook(name)
+	if err != nil || !mentions {
+		return err
+	}
+
+	hookPath, err := git.InstallMentionsHook(hooksDir, chainLocal)
+	if err != nil {
+		return err
+	}
+	internal.PrintVerbose("Installed %s hook at %s to expand @alias mentions", git.CommitMsgHook, hookPath)
+
+	return nil
+}
+
+// installGpairHooksDir sets up ~/.gpair/hooks as the global core.hooksPath,
+// passing every other hook through to each repo's own hooks
+func installGpairHooksDir(name, hooksDir string) error {
+	hookPath, err := git.InstallHook(hooksDir, name, true)
+	if err != nil {
+		return err
+	}
+	internal.PrintVerbose("Installed %s hook at %s", name, hookPath)
+
+	err = git.InstallPassthroughHooks(hooksDir, name)
+	if err != nil {
+		return err
+	}
+
+	return git.SetHooksPath(hooksDir, true)
+}
+
+// useHookBackend switches to the hook backend, moving an existing pairing over from the commit template
+func useHookBackend(name string, global bool) error {
+	err := git.SetBackend(git.HookBackend, global)
+	if err != nil {
+		return err
+	}
+
+	templatePath, err := git.GetTemplate(global)
+	if err != nil {
+		return err
+	}
+
+	if templatePath != "" && git.IsGpairPath(templatePath) {
+		err = git.SetPairing(templatePath, global)
+		if err != nil {
+			return err
+		}
+
+		err = git.RestoreTemplate(global)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("gpair will now add co-authors from the %s hook.\n", name)
+
+	return nil
+}
+
+func uninstallHooks(global bool) error {
+	gpairHooksDir, err := git.GetGlobalHooksDir()
+	if err != nil {
+		return err
+	}
+
+	var hooksDir string
+	if global {
+		hooksDir, err = git.GetHooksPath(true)
+		if err != nil {
+			return err
+		}
+
+		if hooksDir == "" {
+			hooksDir = gpairHooksDir
+		}
+	} else {
+		_, err = git.GetRepoRoot()
+		if err != nil {
+			fmt.Println("gpair must be run inside a git repository unless in global mode")
+			os.Exit(0)
+		}
+
+		hooksDir, err = git.GetHooksDir()
+		if err != nil {
+			return err
+		}
+	}
+
+	// The global gpair hooks are shared by every repo, so they are only removed in global mode
+	if global || hooksDir != gpairHooksDir {
+		removed, err := git.UninstallAllHooks(hooksDir)
+		if err != nil {
+			return err
+		}
+
+		if len(removed) > 0 {
+			internal.PrintVerbose("Removed %s hooks from %s", strings.Join(removed, ", "), hooksDir)
+		}
+	}
+
+	if global && hooksDir == gpairHooksDir {
+		err = git.UnsetHooksPath(true)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = git.UnsetBackend(global)
+	if err != nil {
+		return err
+	}
+
+	// Move an existing pairing back to the commit template, unless a custom template is in the way
+	pairingPath, err := git.GetScopedPairing(global)
+	if err != nil {
+		return err
+	}
+
+	if pairingPath != "" {
+		templatePath, err := git.GetTemplate(global)
+		if err != nil {
+			return err
+		}
+
+		if templatePath == "" {
+			err = git.SetTemplate(pairingPath, global)
+			if err != nil {
+				return err
+			}
+		} else {
+			fmt.Println("Run gpair again to add your co-authors to your commit template.")
+		}
+
+		err = git.UnsetPairing(global)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("gpair will now add co-authors with a commit template.")
+
+	return nil
+}
+
+// runMentions expands the @alias mentions in the commit message in messagePath, in hook mode
+func runMentions(messagePath string) error {
+	backend, err := git.GetBackend()
+	if err != nil || backend != git.HookBackend {
+		return err
+	}
+
+	return expandMentions(messagePath)
+}
+
+// runHook appends the trailers of the active pairing to the commit message in messagePath.
+// The pairing on the current branch is looked up first, in case the post-checkout hook did not run.
+func runHook(messagePath string) error {
+	backend, err := git.GetBackend()
+	if err != nil || backend != git.HookBackend {
+		return err
+	}
+
+	err = followBranch()
+	if err != nil {
+		return err
+	}
+
+	pairingPath, err := git.GetPairing()
+	if err != nil || pairingPath == "" {
+		return err
+	}
+
+	stillPairing, err := confirmPairing()
+	if err != nil || !stillPairing {
+		return err
+	}
+
+	pairingBytes, err := ioutil.ReadFile(pairingPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			internal.PrintVerbose("Pairing file %s does not exist, run gpair again to recreate it", pairingPath)
+			return nil
+		}
+
+		return err
+	}
+
+	messageBytes, err := ioutil.ReadFile(messagePath)
+	if err != nil {
+		return err
+	}
+
+	commentChar, err := git.GetCommentChar(string(messageBytes))
+	if err != nil {
+		return err
+	}
+
+	trailers := git.ExtractTrailers(string(pairingBytes))
+	message := git.MoveSignOffLast(string(messageBytes), trailers, commentChar)
+	message = git.AppendTrailers(message, trailers, commentChar)
+
+	return ioutil.WriteFile(messagePath, []byte(message), 0644)
+}
@@ -0,0 +1,125 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// JoinCmd is the flagset for the 'join' subcommand
+var JoinCmd flag.FlagSet
+
+func init() {
+	JoinCmd = *flag.NewFlagSet("join", flag.ExitOnError)
+	JoinCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	JoinCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	JoinCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	JoinCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	JoinCmd.BoolVar(&globalMode, "global", false, "\nJoin the global pairing")
+	JoinCmd.BoolVar(&globalMode, "g", false, "\nJoin the global pairing (shorthand)")
+	JoinCmd.StringVar(&dirPath, "dir", "", "\nJoin the pairing for every repo in this directory")
+	JoinCmd.StringVar(&trailerKey, "trailer", "", "Credit the alias right after this flag with this trailer instead of Co-authored-by, e.g. 'Reviewed-by'")
+	oldUsage := JoinCmd.Usage
+	JoinCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'join' subcommand adds collaborators to the current pairing without retyping everyone else.")
+		fmt.Println("It can be run with one or more alias as 'gpair join ALIAS_1 [ALIAS_2 ...]'.")
+		fmt.Println("With 'gpair join --trailer Reviewed-by ALIAS', ALIAS is credited with a 'Reviewed-by' trailer instead.")
+		fmt.Println()
+		oldUsage()
+		JoinCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+func parseJoinArgs(args []string) (aliases []string, trailers map[string]string, err error) {
+	aliases, trailers, err = parseAliasArgs(&JoinCmd, args)
+
+	internal.PrintVerbose("Got aliases: %s", strings.Join(aliases, ", "))
+
+	return aliases, trailers, err
+}
+
+// Join is the function executed by the 'join' subcommand
+// It adds the collaborators with the given aliases to the current pairing
+func Join() {
+	aliases, trailers, err := parseJoinArgs(os.Args[2:])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(0)
+	}
+
+	if internal.Help || len(aliases) == 0 {
+		JoinCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	configurator, err := config.NewConfigurator()
+	if err != nil {
+		panic(err)
+	}
+
+	collaborators, err := configurator.GetCollaborators(aliases...)
+	if err != nil {
+		fmt.Println(err.Error())
+	}
+
+	if len(collaborators) == 0 {
+		os.Exit(0)
+	}
+
+	err = useDirFlag(globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	collaborators, err = setTrailers(collaborators, trailers)
+	if err != nil {
+		panic(err)
+	}
+
+	key := getSessionKey(globalMode)
+
+	sessions, err := session.NewManager(key)
+	if err != nil {
+		panic(err)
+	}
+
+	current, err := loadSession(sessions, configurator, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	if current.IsEmpty() {
+		current, err = newSession()
+		if err != nil {
+			panic(err)
+		}
+	}
+	current.Add(collaborators...)
+
+	err = applySession(key, sessions, current, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	var joined []string
+	for _, collaborator := range collaborators {
+		joined = append(joined, collaborator.Alias)
+	}
+	recordEvent(history.Join, joined, current, globalMode)
+
+	fmt.Printf("Now pairing with '%s'\n", strings.Join(current.Aliases, "', '"))
+}
@@ -0,0 +1,129 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// LastCmd is the flagset for the 'last' subcommand
+var LastCmd flag.FlagSet
+
+func init() {
+	LastCmd = *flag.NewFlagSet("last", flag.ExitOnError)
+	LastCmd.StringVar(&sessionLength, "for", "", "Stop pairing automatically after this long, e.g. '3h' or '2d'. Defaults to git config gpair.maxSessionAge")
+	LastCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	LastCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	LastCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	LastCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	LastCmd.BoolVar(&globalMode, "global", false, "\nPair with whoever you last paired with globally")
+	LastCmd.BoolVar(&globalMode, "g", false, "\nPair with whoever you last paired with globally (shorthand)")
+	LastCmd.StringVar(&dirPath, "dir", "", "\nPair with whoever you last paired with for every repo in this directory")
+	oldUsage := LastCmd.Usage
+	LastCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'last' subcommand pairs again with whoever you paired with most recently in the current repo.")
+		fmt.Println()
+		oldUsage()
+		LastCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// Last is the function executed by the 'last' subcommand
+// It starts a new pairing with the coauthors of the previous session
+func Last() {
+	err := LastCmd.Parse(os.Args[2:])
+	if err != nil {
+		panic(err)
+	}
+
+	if internal.Help {
+		LastCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	err = useDirFlag(globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	configurator, err := config.NewConfigurator()
+	if err != nil {
+		panic(err)
+	}
+
+	key := getSessionKey(globalMode)
+
+	sessions, err := session.NewManager(key)
+	if err != nil {
+		panic(err)
+	}
+
+	last, err := sessions.GetLast()
+	if err != nil {
+		panic(err)
+	}
+
+	if last.IsEmpty() {
+		fmt.Println("You have not paired with anyone here before")
+		os.Exit(0)
+	}
+
+	roster, err := configurator.GetCollaborators()
+	if err != nil {
+		panic(err)
+	}
+
+	current := restoreSession(last, roster)
+	current.Expires, err = getExpiry(current.Start, sessionLength)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(0)
+	}
+
+	err = unbindSession(sessions)
+	if err != nil {
+		panic(err)
+	}
+
+	err = applySession(key, sessions, current, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	recordEvent(history.Pair, current.Aliases, current, globalMode)
+
+	fmt.Printf("Now pairing with '%s'\n", strings.Join(current.Aliases, "', '"))
+}
+
+// restoreSession returns a new session with the coauthors of last, starting now. The name and email of each coauthor
+// are refreshed from the roster, falling back on those saved with the session, and the trailer crediting them is kept,
+// as is the trailer style of the session.
+func restoreSession(last session.Session, roster []config.Collaborator) session.Session {
+	current := session.NewSession()
+	current.Style = last.Style
+	for _, coauthor := range last.GetCoauthors() {
+		for _, collab := range roster {
+			if collab.Alias == coauthor.Alias {
+				coauthor.Name, coauthor.Email = collab.Name, collab.Email
+				break
+			}
+		}
+		current.Add(coauthor)
+	}
+
+	return current
+}
@@ -0,0 +1,39 @@
+package subcommands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+func Test_restoreSession(t *testing.T) {
+	roster := []config.Collaborator{
+		config.NewCollaborator("al", "Alice", "alice@example.com"),
+		config.NewCollaborator("bo", "Bob Smith", "bob@new.example.com"),
+	}
+
+	al := config.NewCollaborator("al", "Alice", "alice@example.com")
+	bo := config.NewCollaborator("bo", "Bob", "bob@example.com")
+	bo.Trailer = "Reviewed-by"
+	cy := config.NewCollaborator("cy", "Cy", "cy@example.com")
+	cy.Trailer = "Helped-by"
+
+	wantBo := config.NewCollaborator("bo", "Bob Smith", "bob@new.example.com")
+	wantBo.Trailer = "Reviewed-by"
+
+	last := session.NewSession(al, bo, cy)
+	last.Style = git.DCOStyle
+	got := restoreSession(last, roster)
+
+	// Removed collaborators are restored as they were saved with the session
+	want := []config.Collaborator{al, wantBo, cy}
+	if !reflect.DeepEqual(got.GetCoauthors(), want) {
+		t.Errorf("restoreSession() coauthors = %v, want %v", got.GetCoauthors(), want)
+	}
+	if got.Style != git.DCOStyle {
+		t.Errorf("restoreSession() style = %q, want %q", got.Style, git.DCOStyle)
+	}
+}
@@ -0,0 +1,120 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// LeaveCmd is the flagset for the 'leave' subcommand
+var LeaveCmd flag.FlagSet
+
+func init() {
+	LeaveCmd = *flag.NewFlagSet("leave", flag.ExitOnError)
+	LeaveCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	LeaveCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	LeaveCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	LeaveCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	LeaveCmd.BoolVar(&globalMode, "global", false, "\nLeave the global pairing")
+	LeaveCmd.BoolVar(&globalMode, "g", false, "\nLeave the global pairing (shorthand)")
+	LeaveCmd.StringVar(&dirPath, "dir", "", "\nLeave the pairing for every repo in this directory")
+	oldUsage := LeaveCmd.Usage
+	LeaveCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'leave' subcommand removes collaborators from the current pairing, keeping everyone else.")
+		fmt.Println("It can be run with one or more alias as 'gpair leave ALIAS_1 [ALIAS_2 ...]'.")
+		fmt.Println("When the last collaborator leaves, you are back to working solo.")
+		fmt.Println()
+		oldUsage()
+		LeaveCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+func parseLeaveArgs(args []string) (aliases []string, err error) {
+	aliases, err = parseInterspersed(&LeaveCmd, args)
+
+	internal.PrintVerbose("Got aliases: %s", strings.Join(aliases, ", "))
+
+	return aliases, err
+}
+
+// Leave is the function executed by the 'leave' subcommand
+// It removes the collaborators with the given aliases from the current pairing
+func Leave() {
+	aliases, err := parseLeaveArgs(os.Args[2:])
+	if err != nil {
+		panic(err)
+	}
+
+	if internal.Help || len(aliases) == 0 {
+		LeaveCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	configurator, err := config.NewConfigurator()
+	if err != nil {
+		panic(err)
+	}
+
+	err = useDirFlag(globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	key := getSessionKey(globalMode)
+
+	sessions, err := session.NewManager(key)
+	if err != nil {
+		panic(err)
+	}
+
+	current, err := loadSession(sessions, configurator, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	missing := current.Remove(aliases...)
+	if len(missing) > 0 {
+		fmt.Printf("Not pairing with '%s'\n", strings.Join(missing, "', '"))
+	}
+
+	var left []string
+	for _, alias := range aliases {
+		if !contains(missing, alias) {
+			left = append(left, alias)
+		}
+	}
+
+	if current.IsEmpty() {
+		err = endSession(sessions, globalMode)
+		if err != nil {
+			panic(err)
+		}
+
+		recordEvent(history.Leave, left, current, globalMode)
+		fmt.Println("Nobody left to pair with, working solo")
+		return
+	}
+
+	err = applySession(key, sessions, current, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	recordEvent(history.Leave, left, current, globalMode)
+
+	fmt.Printf("Now pairing with '%s'\n", strings.Join(current.Aliases, "', '"))
+}
@@ -0,0 +1,279 @@
+package subcommands
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// LintCmd is the flagset for the 'lint' subcommand
+var LintCmd flag.FlagSet
+
+var lintFormat string
+
+var rosterPath string
+
+// Output formats of the 'lint' subcommand
+const (
+	humanFormat  = "human"
+	jsonFormat   = "json"
+	githubFormat = "github"
+)
+
+// Rules the 'lint' subcommand checks the trailers of each commit against
+const (
+	ruleMalformed = "malformed"
+	ruleUnknown   = "unknown-coauthor"
+	ruleAuthor    = "duplicates-author"
+	ruleRepeated  = "repeated"
+	ruleDCO       = "dco"
+)
+
+// emailPattern matches what can pass for an email address in a trailer
+var emailPattern = regexp.MustCompile(`^[^\s@<>]+@[^\s@<>]+$`)
+
+func init() {
+	LintCmd = *flag.NewFlagSet("lint", flag.ExitOnError)
+	LintCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	LintCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	LintCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	LintCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	LintCmd.StringVar(&lintFormat, "format", humanFormat, "The output format: 'human', 'json', or 'github' for GitHub Actions annotations")
+	LintCmd.StringVar(&rosterPath, "roster", "", "Check co-authors against the collaborators in this file, in the format of ~/.gpair/config.json")
+	LintCmd.BoolVar(&dcoMode, "dco", false, "\nAlso check the Co-developed-by and Signed-off-by trailers of the Linux kernel style. Defaults to git config gpair.trailerStyle")
+	oldUsage := LintCmd.Usage
+	LintCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'lint' subcommand checks the co-author trailers of the commits in a range, such as 'gpair lint main..HEAD'.")
+		fmt.Println("Each Co-authored-by trailer must be of the form 'Name <email>', credit a collaborator in your roster,")
+		fmt.Println("not credit the author of the commit, and appear only once.")
+		fmt.Println("The roster is your own collaborators, or the file given with --roster, such as one committed to the repo for CI.")
+		fmt.Println("gpair exits with status 1 if any commit breaks these rules, and 2 if the range is not valid.")
+		fmt.Println()
+		oldUsage()
+		LintCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// violation is a trailer of a commit that breaks one of the rules of the 'lint' subcommand
+type violation struct {
+	Commit  string `json:"commit"`
+	Subject string `json:"subject"`
+	Rule    string `json:"rule"`
+	Trailer string `json:"trailer,omitempty"`
+	Message string `json:"message"`
+}
+
+// Lint is the function executed by the 'lint' subcommand
+// It checks the co-author trailers of the commits in a range, and exits with status 1 if any is wrong
+func Lint() {
+	args, err := parseInterspersed(&LintCmd, os.Args[2:])
+	if err != nil {
+		panic(err)
+	}
+
+	if internal.Help {
+		LintCmd.Usage()
+		os.Exit(0)
+	}
+
+	if len(args) != 1 {
+		fmt.Println("Give a single revision range to check, such as 'gpair lint main..HEAD'")
+		os.Exit(2)
+	}
+
+	if lintFormat != humanFormat && lintFormat != jsonFormat && lintFormat != githubFormat {
+		fmt.Printf("Unknown format '%s', use 'human', 'json' or 'github'\n", lintFormat)
+		os.Exit(2)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(2)
+	}
+
+	roster, err := getRoster()
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+
+	if len(roster) == 0 {
+		internal.PrintVerbose("The roster is empty, so co-authors are not checked against it")
+	}
+
+	style, err := getTrailerStyle()
+	if err != nil {
+		panic(err)
+	}
+
+	commits, err := git.GetCommits(args[0])
+	if err != nil {
+		if err, ok := err.(*git.ErrRevisionRange); ok {
+			fmt.Println(err.Error())
+			os.Exit(2)
+		}
+
+		panic(err)
+	}
+
+	var violations []violation
+	for _, commit := range commits {
+		violations = append(violations, lintCommit(commit, roster, style == git.DCOStyle)...)
+	}
+
+	printViolations(violations, len(commits))
+
+	if len(violations) > 0 {
+		os.Exit(1)
+	}
+}
+
+// getRoster returns the collaborators in the file given with --roster, or else the user's own
+func getRoster() ([]config.Collaborator, error) {
+	if rosterPath != "" {
+		return config.ReadCollaborators(rosterPath)
+	}
+
+	configurator, err := config.NewConfigurator()
+	if err != nil {
+		return nil, err
+	}
+
+	return configurator.GetCollaborators()
+}
+
+// lintCommit returns the violations of the co-author trailers of a commit.
+// With an empty roster, co-authors are not checked against it. With dco, the Linux kernel style is checked too.
+// A committed message has no comments, so no line of it is skipped as one.
+func lintCommit(commit git.Commit, roster []config.Collaborator, dco bool) []violation {
+	var violations []violation
+	add := func(rule string, trailer git.Trailer, format string, args ...interface{}) {
+		violations = append(violations, violation{
+			Commit:  commit.SHA,
+			Subject: commit.Subject(),
+			Rule:    rule,
+			Trailer: trailer.String(),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	trailers := git.ParseTrailers(commit.Message, git.NoComments)
+	seen := make(map[string]bool)
+	for _, trailer := range trailers {
+		if !strings.EqualFold(trailer.Key, config.DefaultTrailer) {
+			continue
+		}
+
+		coauthor, ok := config.ParseCredit(trailer.String())
+		if !ok || coauthor.Name == "" || !emailPattern.MatchString(coauthor.Email) {
+			add(ruleMalformed, trailer, "'%s' is not of the form '%s: Name <email>'", trailer, config.DefaultTrailer)
+			continue
+		}
+
+		email := strings.ToLower(coauthor.Email)
+		if email == strings.ToLower(commit.Author.Email) {
+			add(ruleAuthor, trailer, "%s <%s> is the author of the commit", coauthor.Name, coauthor.Email)
+			continue
+		}
+
+		if seen[email] {
+			add(ruleRepeated, trailer, "%s is credited more than once", coauthor.Email)
+			continue
+		}
+		seen[email] = true
+
+		if _, ok := config.FindByEmail(roster, coauthor.Email); len(roster) > 0 && !ok {
+			add(ruleUnknown, trailer, "%s is not in the roster", coauthor.Email)
+		}
+	}
+
+	if dco {
+		var lines []string
+		for _, trailer := range trailers {
+			lines = append(lines, trailer.String())
+		}
+
+		for _, problem := range git.ValidateDCO(lines, commit.Author, commit.Committer) {
+			add(ruleDCO, git.Trailer{}, "%s", problem)
+		}
+	}
+
+	return violations
+}
+
+// printViolations prints the violations found in a number of commits in the format given with --format
+func printViolations(violations []violation, commits int) {
+	switch lintFormat {
+	case jsonFormat:
+		if violations == nil {
+			violations = []violation{}
+		}
+
+		jsonBytes, err := json.MarshalIndent(struct {
+			Commits    int         `json:"commits"`
+			Violations []violation `json:"violations"`
+		}{commits, violations}, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Println(string(jsonBytes))
+		return
+
+	case githubFormat:
+		for _, v := range violations {
+			fmt.Printf("::error title=%s::%s\n", escapeAnnotation("gpair lint "+v.Rule, true),
+				escapeAnnotation(fmt.Sprintf("%s %s: %s", shortSHA(v.Commit), v.Subject, v.Message), false))
+		}
+
+	default:
+		last := ""
+		for _, v := range violations {
+			if v.Commit != last {
+				fmt.Printf("%s %s\n", shortSHA(v.Commit), v.Subject)
+				last = v.Commit
+			}
+			fmt.Printf("  %s: %s\n", v.Rule, v.Message)
+		}
+	}
+
+	if len(violations) == 0 {
+		fmt.Printf("Checked %d commits, no problems found\n", commits)
+		return
+	}
+
+	broken := make(map[string]bool)
+	for _, v := range violations {
+		broken[v.Commit] = true
+	}
+
+	fmt.Printf("Found %d problems in %d of %d commits\n", len(violations), len(broken), commits)
+}
+
+// escapeAnnotation escapes a value for a GitHub Actions workflow command, which is stricter for properties
+func escapeAnnotation(value string, property bool) string {
+	value = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+	if property {
+		value = strings.NewReplacer(":", "%3A", ",", "%2C").Replace(value)
+	}
+
+	return value
+}
+
+// shortSHA returns the abbreviated form of a commit SHA
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+
+	return sha
+}
@@ -0,0 +1,61 @@
+package subcommands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+func TestLintCommit(t *testing.T) {
+	me := config.Collaborator{Name: "Me", Email: "me@example.com"}
+	roster := []config.Collaborator{
+		config.NewCollaborator("alice", "Alice", "alice@example.com"),
+		config.NewCollaborator("bob", "Bob", "bob@example.com"),
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		roster  []config.Collaborator
+		dco     bool
+		want    []string
+	}{
+		{"valid", "fix\n\nCo-authored-by: Alice <alice@example.com>\nCo-authored-by: Bob <bob@example.com>\n", roster, false, nil},
+		{"no trailers", "fix\n", roster, false, nil},
+		{"malformed", "fix\n\nCo-authored-by: Alice\nCo-authored-by: <bob@example.com>\nCo-authored-by: Bob <bob>\n", roster, false,
+			[]string{ruleMalformed, ruleMalformed, ruleMalformed}},
+		{"unknown", "fix\n\nCo-authored-by: Carol <carol@example.com>\n", roster, false, []string{ruleUnknown}},
+		{"no roster", "fix\n\nCo-authored-by: Carol <carol@example.com>\n", nil, false, nil},
+		{"author", "fix\n\nCo-authored-by: Me <ME@example.com>\n", roster, false, []string{ruleAuthor}},
+		{"repeated", "fix\n\nCo-authored-by: Alice <alice@example.com>\nco-authored-by: A. <Alice@example.com>\n", roster, false, []string{ruleRepeated}},
+		{"other trailers", "fix\n\nReviewed-by: Carol <carol@example.com>\n", roster, false, nil},
+		{"dco", "fix\n\nCo-developed-by: Alice <alice@example.com>\nSigned-off-by: Alice <alice@example.com>\nSigned-off-by: Me <me@example.com>\n", roster, true, nil},
+		{"dco out of order", "fix\n\nSigned-off-by: Me <me@example.com>\nCo-developed-by: Alice <alice@example.com>\nSigned-off-by: Alice <alice@example.com>\n", roster, true, []string{ruleDCO}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commit := git.Commit{SHA: "abc", Author: me, Committer: me, Message: tt.message}
+
+			var got []string
+			for _, v := range lintCommit(commit, tt.roster, tt.dco) {
+				got = append(got, v.Rule)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lintCommit() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeAnnotation(t *testing.T) {
+	if got := escapeAnnotation("50% done\nnext", false); got != "50%25 done%0Anext" {
+		t.Errorf("escapeAnnotation() = %q", got)
+	}
+
+	if got := escapeAnnotation("a: b, c", true); got != "a%3A b%2C c" {
+		t.Errorf("escapeAnnotation() of a property = %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package subcommands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// errUnknownMention is returned when a commit message mentions an alias that is not among the collaborators
+type errUnknownMention struct {
+	alias      string
+	suggestion string
+}
+
+func (err *errUnknownMention) Error() string {
+	message := fmt.Sprintf("No collaborator exists for the alias '@%s' mentioned in the commit message.", err.alias)
+	if err.suggestion != "" {
+		message += fmt.Sprintf(" Did you mean '@%s'?", err.suggestion)
+	}
+
+	return message
+}
+
+// expandMentions appends the trailers of the collaborators mentioned as @alias in the commit message in messagePath,
+// and removes the mentions unless gpair.mentions is "keep". An unknown alias returns an errUnknownMention,
+// unless it is capitalized like the @Override of an annotation, in which case it is left alone.
+func expandMentions(messagePath string) error {
+	mode, err := git.GetMentionsMode()
+	if err != nil || mode == git.MentionsOff {
+		return err
+	}
+
+	messageBytes, err := ioutil.ReadFile(messagePath)
+	if err != nil {
+		return err
+	}
+	message := string(messageBytes)
+
+	commentChar, err := git.GetCommentChar(message)
+	if err != nil {
+		return err
+	}
+
+	aliases := git.FindMentions(message, commentChar)
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	configurator, err := config.NewConfigurator()
+	if err != nil {
+		return err
+	}
+
+	collaborators, err := getMentioned(configurator, aliases)
+	if err != nil {
+		return err
+	}
+
+	self, err := git.GetUser()
+	if err != nil {
+		return err
+	}
+
+	var coauthors []config.Collaborator
+	for _, collaborator := range collaborators {
+		// Mentioning yourself credits nobody
+		if !strings.EqualFold(collaborator.Email, self.Email) {
+			coauthors = append(coauthors, collaborator)
+		}
+	}
+
+	coauthors, err = setTrailers(coauthors, nil)
+	if err != nil {
+		return err
+	}
+
+	style, err := git.GetTrailerStyle()
+	if err != nil {
+		return err
+	}
+
+	if mode != git.MentionsKeep {
+		var mentioned []string
+		for _, collaborator := range collaborators {
+			mentioned = append(mentioned, collaborator.Alias)
+		}
+
+		message = git.StripMentions(message, commentChar, mentioned)
+	}
+
+	if len(coauthors) > 0 {
+		trailers := git.GetTrailers(style, self, coauthors...)
+		message = git.MoveSignOffLast(message, trailers, commentChar)
+		message = git.AppendTrailers(message, trailers, commentChar)
+	}
+
+	return ioutil.WriteFile(messagePath, []byte(message), 0644)
+}
+
+// getMentioned returns the collaborators with the given aliases, or an errUnknownMention for the first one missing.
+// Unknown aliases that are capitalized are skipped, since they are more likely code than a person.
+func getMentioned(configurator config.Configurator, aliases []string) ([]config.Collaborator, error) {
+	roster, err := configurator.GetCollaborators()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	for _, collaborator := range roster {
+		known[collaborator.Alias] = true
+	}
+
+	var mentioned []string
+	for _, alias := range aliases {
+		if known[alias] {
+			mentioned = append(mentioned, alias)
+			continue
+		}
+
+		if first, _ := utf8.DecodeRuneInString(alias); unicode.IsUpper(first) {
+			continue
+		}
+
+		suggestion, _ := config.ClosestAlias(roster, alias)
+		return nil, &errUnknownMention{alias, suggestion}
+	}
+
+	if len(mentioned) == 0 {
+		return nil, nil
+	}
+
+	return configurator.GetCollaborators(mentioned...)
+}
@@ -0,0 +1,33 @@
+package subcommands
+
+import (
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+func TestGetMentioned(t *testing.T) {
+	configurator := config.NewMockConfigurator(config.NewConfig())
+	for _, alias := range []string{"alice", "bob"} {
+		err := configurator.AddCollaborator(config.NewCollaborator(alias, alias, alias+"@example.com"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collaborators, err := getMentioned(configurator, []string{"bob", "alice"})
+	if err != nil || len(collaborators) != 2 || collaborators[0].Alias != "bob" {
+		t.Errorf("getMentioned() = %v, %v, want bob and alice", collaborators, err)
+	}
+
+	_, err = getMentioned(configurator, []string{"alice", "alcie"})
+	eum, ok := err.(*errUnknownMention)
+	if !ok || eum.alias != "alcie" || eum.suggestion != "alice" {
+		t.Errorf("getMentioned() error = %v, want an unknown mention of alcie suggesting alice", err)
+	}
+
+	collaborators, err = getMentioned(configurator, []string{"Override", "alice"})
+	if err != nil || len(collaborators) != 1 || collaborators[0].Alias != "alice" {
+		t.Errorf("getMentioned() = %v, %v, want only alice", collaborators, err)
+	}
+}
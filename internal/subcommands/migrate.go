@@ -0,0 +1,88 @@
+package subcommands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// migrateRepoState moves the pairing of the current repo over from the files gpair used to name after the repo alone,
+// which two checkouts with the same name would share, to the files named after its key.
+// The old files are only taken over if the repo's git config points at the old template, since another checkout
+// with the same name may use them too, so they are copied rather than moved.
+func migrateRepoState(repoName, key string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	gpairDir := filepath.Join(home, ".gpair")
+	oldTemplate := filepath.Join(gpairDir, repoName+"-template.txt")
+	newTemplate := filepath.Join(gpairDir, key+"-template.txt")
+
+	if _, err := os.Stat(oldTemplate); err != nil {
+		return nil
+	}
+
+	templatePath, err := git.GetTemplate(false)
+	if err != nil {
+		return err
+	}
+
+	pairingPath, err := git.GetScopedPairing(false)
+	if err != nil {
+		return err
+	}
+
+	if templatePath != oldTemplate && pairingPath != oldTemplate {
+		return nil
+	}
+
+	internal.PrintVerbose("Moving the pairing of this repo from %s to %s", oldTemplate, newTemplate)
+
+	err = copyIfMissing(oldTemplate, newTemplate)
+	if err != nil {
+		return err
+	}
+
+	for _, suffix := range []string{".json", ".last.json", ".branches.json"} {
+		err = copyIfMissing(filepath.Join(gpairDir, "sessions", repoName+suffix), filepath.Join(gpairDir, "sessions", key+suffix))
+		if err != nil {
+			return err
+		}
+	}
+
+	if templatePath == oldTemplate {
+		err = git.SetTemplate(newTemplate, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if pairingPath == oldTemplate {
+		err = git.SetPairing(newTemplate, false)
+	}
+
+	return err
+}
+
+// copyIfMissing copies the file at src to dst, unless src does not exist or dst already has contents
+func copyIfMissing(src, dst string) error {
+	if stats, err := os.Stat(dst); err == nil && stats.Size() > 0 {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(src)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	return ioutil.WriteFile(dst, contents, 0600)
+}
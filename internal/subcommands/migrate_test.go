@@ -0,0 +1,172 @@
+package subcommands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+func Test_copyIfMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gpair_migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "api-template.txt")
+	dst := filepath.Join(dir, "api-0123456789ab-template.txt")
+
+	// A missing source is not an error, since not every session file exists
+	if err := copyIfMissing(src, dst); err != nil {
+		t.Fatalf("copyIfMissing() error = %v", err)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Errorf("copyIfMissing() created %s from a missing source", dst)
+	}
+
+	if err := ioutil.WriteFile(src, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty destination, as created by a store, is replaced
+	if err := ioutil.WriteFile(dst, []byte{}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyIfMissing(src, dst); err != nil {
+		t.Fatalf("copyIfMissing() error = %v", err)
+	}
+	if got, _ := ioutil.ReadFile(dst); string(got) != "old" {
+		t.Errorf("copyIfMissing() wrote %q, want %q", got, "old")
+	}
+
+	if err := ioutil.WriteFile(dst, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyIfMissing(src, dst); err != nil {
+		t.Fatalf("copyIfMissing() error = %v", err)
+	}
+	if got, _ := ioutil.ReadFile(dst); string(got) != "new" {
+		t.Errorf("copyIfMissing() overwrote %s with %q", dst, got)
+	}
+}
+
+func Test_migrateRepoState(t *testing.T) {
+	tests := []struct {
+		name string
+		// configKey is the git config that points at the old template, or "" if the repo does not use it
+		configKey   string
+		wantMigrate bool
+	}{
+		{"template backend", "commit.template", true},
+		{"hook backend", "gpair.pairing", true},
+		{"another checkout with the same name", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, cleanup := enterTestRepo(t)
+			defer cleanup()
+
+			home, err := os.UserHomeDir()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			repoName, err := git.GetRepoName()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := git.GetRepoKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			gpairDir := filepath.Join(home, ".gpair")
+			sessionsDir := filepath.Join(gpairDir, "sessions")
+			if err := os.MkdirAll(sessionsDir, 0700); err != nil {
+				t.Fatal(err)
+			}
+
+			oldTemplate := filepath.Join(gpairDir, repoName+"-template.txt")
+			newTemplate := filepath.Join(gpairDir, key+"-template.txt")
+			files := map[string]string{
+				oldTemplate: "\n\nCo-authored-by: Alice <alice@example.com>\n",
+				filepath.Join(sessionsDir, repoName+".json"):      `{"aliases":["alice"]}`,
+				filepath.Join(sessionsDir, repoName+".last.json"): `{"aliases":["bob"]}`,
+			}
+			for path, contents := range files {
+				if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.configKey != "" {
+				runGit(t, "config", tt.configKey, oldTemplate)
+			}
+
+			err = migrateRepoState(repoName, key)
+			if err != nil {
+				t.Fatalf("migrateRepoState() error = %v", err)
+			}
+
+			moved := map[string]string{
+				newTemplate:                                  files[oldTemplate],
+				filepath.Join(sessionsDir, key+".json"):      files[filepath.Join(sessionsDir, repoName+".json")],
+				filepath.Join(sessionsDir, key+".last.json"): files[filepath.Join(sessionsDir, repoName+".last.json")],
+			}
+			for path, want := range moved {
+				got, err := ioutil.ReadFile(path)
+				if !tt.wantMigrate {
+					if !os.IsNotExist(err) {
+						t.Errorf("migrateRepoState() wrote %s for a repo that does not use the old files", path)
+					}
+					continue
+				}
+
+				if err != nil || string(got) != want {
+					t.Errorf("migrateRepoState() wrote %q to %s, %v, want %q", got, path, err, want)
+				}
+			}
+
+			// The old files are copied, since another checkout with the same name may still use them
+			for path := range files {
+				if _, err := os.Stat(path); err != nil {
+					t.Errorf("migrateRepoState() removed %s", path)
+				}
+			}
+
+			if tt.configKey == "" {
+				return
+			}
+
+			want := oldTemplate
+			if tt.wantMigrate {
+				want = newTemplate
+			}
+			if got := strings.TrimSpace(runGit(t, "config", tt.configKey)); got != want {
+				t.Errorf("%s = %s after migrateRepoState(), want %s", tt.configKey, got, want)
+			}
+
+			// Once moved, the repo no longer uses the old files, so changes to them are not taken over again
+			if err := ioutil.WriteFile(filepath.Join(sessionsDir, repoName+".json"), []byte(`{"aliases":["cy"]}`), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			err = migrateRepoState(repoName, key)
+			if err != nil {
+				t.Fatalf("migrateRepoState() the second time error = %v", err)
+			}
+
+			if got, _ := ioutil.ReadFile(filepath.Join(sessionsDir, key+".json")); string(got) != moved[filepath.Join(sessionsDir, key+".json")] {
+				t.Errorf("migrateRepoState() the second time changed the session to %q", got)
+			}
+			if got := strings.TrimSpace(runGit(t, "config", tt.configKey)); got != newTemplate {
+				t.Errorf("%s = %s after migrateRepoState() the second time, want %s", tt.configKey, got, newTemplate)
+			}
+		})
+	}
+}
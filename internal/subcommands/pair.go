@@ -6,7 +6,8 @@ import (
 	"os"
 
 	"github.com/adavidalbertson/gpair/internal/git"
-	"github.com/adavidalbertson/gpair/internal/store"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
 
 	"github.com/adavidalbertson/gpair/internal/config"
 
@@ -15,13 +16,30 @@ import (
 
 var globalMode bool
 
+var sessionLength string
+
+var branchMode bool
+
+var worktreeMode bool
+
+// dcoMode is the value of the --dco flag, which credits coauthors in the Linux kernel's Co-developed-by style
+var dcoMode bool
+
 func init() {
 	flag.BoolVar(&internal.Help, "help", false, "Display usage information")
 	flag.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
 	flag.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
 	flag.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
-	flag.BoolVar(&globalMode, "global", false, "\nPair in global mode")
+	flag.BoolVar(&globalMode, "global", false, "\nPair in global mode. A pairing in the current repo still takes precedence, see 'gpair status'")
 	flag.BoolVar(&globalMode, "g", false, "\nPair in global mode (shorthand)")
+	flag.StringVar(&dirPath, "dir", "", "\nPair for every repo in this directory, such as ~/work/acme. A pairing in a repo still takes precedence")
+	flag.StringVar(&sessionLength, "for", "", "Stop pairing automatically after this long, e.g. '3h' or '2d'. Defaults to git config gpair.maxSessionAge")
+	flag.BoolVar(&branchMode, "branch", false, "\nOnly pair on the current branch, switching pairings when you check out another one")
+	flag.StringVar(&reposGlob, "repos", "", "\nPair in every repo matching this glob, such as '~/src/acme-*'")
+	flag.BoolVar(&recurseSubmodules, "recurse-submodules", false, "\nAlso pair in the submodules of the repo, or of every repo matching --repos")
+	flag.StringVar(&trailerKey, "trailer", "", "Credit the alias right after this flag with this trailer instead of Co-authored-by, e.g. 'Reviewed-by'")
+	flag.BoolVar(&dcoMode, "dco", false, "\nCredit each coauthor with Co-developed-by and Signed-off-by, and sign off yourself last, as the Linux kernel requires")
+	flag.BoolVar(&worktreeMode, "worktree", false, "\nGive the current worktree its own pairing, enabling git's extensions.worktreeConfig")
 	oldUsage := flag.Usage
 	flag.Usage = func() {
 		fmt.Println()
@@ -32,16 +50,56 @@ func init() {
 		fmt.Println("To add a collaborator, use the 'add' subcommand. For more information, run 'gpair add -h'.")
 		fmt.Println("To remove a collaborator, use the 'remove' subcommand. For more information, run 'gpair remove -h'")
 		fmt.Println("To stop pairing, run the 'gpair solo' subcommand. For more information, run 'gpair solo -h'")
+		fmt.Println("To see who you are pairing with, run 'gpair status'")
+		fmt.Println("To see everywhere you are pairing, run 'gpair sessions'")
+		fmt.Println("To customize the commit template, see 'gpair template -h'")
+		fmt.Println("To check the co-author trailers of a range of commits, see 'gpair lint -h'")
+		fmt.Println("To credit collaborators on commits you have already made, see 'gpair amend -h' and 'gpair credit -h'")
+		fmt.Println("To credit everyone who worked on a branch you squash-merge, see 'gpair squash-message -h'")
+		fmt.Println("To see how often each pair of people committed together, see 'gpair stats -h'")
+		fmt.Println("With --branch, the pairing follows the current branch: it stops when you check out another branch and")
+		fmt.Println("comes back when you check this one out again.")
+		fmt.Println("In a repo with several worktrees, gpair offers to give each worktree its own pairing.")
+		fmt.Println("With --dir PATH, the pairing applies to every repo in PATH, taking precedence over a global pairing.")
+		fmt.Println("With --trailer KEY ALIAS, ALIAS is credited with a KEY trailer, such as 'Reviewed-by', instead of 'Co-authored-by'.")
+		fmt.Println("Set git's gpair.trailer to change the trailer for everyone else.")
+		fmt.Println("With --dco, or git's gpair.trailerStyle set to 'dco' or 'kernel', each coauthor gets a 'Co-developed-by' trailer")
+		fmt.Println("immediately followed by their 'Signed-off-by', and your own 'Signed-off-by' comes last.")
+		fmt.Println(repoFlagsUsage())
 		fmt.Println()
 		oldUsage()
 		fmt.Println()
 	}
 }
 
+// parseInterspersed parses flags that may appear before, between or after positional arguments,
+// as in 'gpair ALIAS --for 3h', and returns the positional arguments
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 // Pair is the function executed if no subcommand is passed in
 // It prints the git pairing clauses for the collaborators with the given aliases
 func Pair() {
-	flag.Parse()
+	aliases, trailers, err := parseAliasArgs(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Println(err.Error())
+		exitWithError()
+	}
 
 	if internal.Help {
 		flag.Usage()
@@ -53,14 +111,13 @@ func Pair() {
 		panic(err)
 	}
 
-	aliases := flag.Args()
 	collaborators, err := configurator.GetCollaborators(aliases...)
 	if err != nil {
 		fmt.Println(err.Error())
 	}
 
 	if len(collaborators) == 0 {
-		os.Exit(0)
+		exitWithError()
 	}
 
 	if !git.IsInstalled() {
@@ -68,46 +125,69 @@ func Pair() {
 		os.Exit(0)
 	}
 
-	isCustomTemplate, err := git.IsCustomTemplate()
+	if isMultiRepo() {
+		runInRepos(globalMode)
+		return
+	}
+
+	collaborators, err = setTrailers(collaborators, trailers)
 	if err != nil {
 		panic(err)
 	}
 
-	if isCustomTemplate {
-		fmt.Println("It looks like you are using a custom git commit template already.")
-		os.Exit(0)
+	if globalMode && branchMode {
+		fmt.Println("A global pairing cannot be bound to a branch")
+		exitWithError()
 	}
 
-	var repoName string
-	if globalMode {
-		repoName = "gpair-global"
-	} else {
-		repoName, err = git.GetRepoName()
+	if dirPath != "" && branchMode {
+		fmt.Println("A pairing for a directory cannot be bound to a branch")
+		exitWithError()
+	}
+
+	if !globalMode && dirPath == "" {
+		err = offerWorktreeScope(worktreeMode)
 		if err != nil {
-			fmt.Println("gpair must be run inside a git repository unless in global mode")
-			os.Exit(0)
+			panic(err)
 		}
 	}
 
-	templatePath, err := git.CreateTemplate(repoName, collaborators...)
+	err = useDirFlag(globalMode)
 	if err != nil {
-		if efi, ok := err.(*store.ErrFileInaccessible); ok {
-			fmt.Printf("Failed to create template file at %s. Make sure appropriate permissions are set.\n", efi.Path)
-			os.Exit(0)
-		}
+		panic(err)
+	}
+
+	key := getSessionKey(globalMode)
 
+	sessions, err := session.NewManager(key)
+	if err != nil {
 		panic(err)
 	}
 
-	err = git.SetTemplate(templatePath, globalMode)
+	current, err := newSession(collaborators...)
 	if err != nil {
 		panic(err)
 	}
 
-	for _, collaborator := range collaborators {
-		internal.PrintVerbose(collaborator.String())
+	if branchMode {
+		err = bindToBranch(&current)
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		err = unbindSession(sessions)
+		if err != nil {
+			panic(err)
+		}
 	}
 
+	err = applySession(key, sessions, current, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	recordEvent(history.Pair, current.Aliases, current, globalMode)
+
 	if globalMode {
 		internal.PrintVerbose("Global config will be overridden by per-repo config")
 	}
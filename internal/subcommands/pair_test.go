@@ -0,0 +1,72 @@
+package subcommands
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       string
+		wantArgs   []string
+		wantGlobal bool
+		wantFor    string
+	}{
+		{"flags first", "-g -for 3h al bo", []string{"al", "bo"}, true, "3h"},
+		{"flags last", "al bo --for 3h -g", []string{"al", "bo"}, true, "3h"},
+		{"flags between", "al --for 3h bo", []string{"al", "bo"}, false, "3h"},
+		{"no flags", "al", []string{"al"}, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := flag.NewFlagSet("gpair", flag.ContinueOnError)
+			global := flags.Bool("g", false, "")
+			length := flags.String("for", "", "")
+
+			gotArgs, err := parseInterspersed(flags, strings.Split(tt.args, " "))
+			if err != nil {
+				t.Fatalf("parseInterspersed() error = %v", err)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+				t.Errorf("parseInterspersed() = %v, want %v", gotArgs, tt.wantArgs)
+			}
+			if *global != tt.wantGlobal {
+				t.Errorf("got global %v, want %v", *global, tt.wantGlobal)
+			}
+			if *length != tt.wantFor {
+				t.Errorf("got for %s, want %s", *length, tt.wantFor)
+			}
+		})
+	}
+}
+
+func TestGetExpiry(t *testing.T) {
+	start := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		length  string
+		want    time.Time
+		wantErr bool
+	}{
+		{"3h", start.Add(3 * time.Hour), false},
+		{"2d", start.Add(48 * time.Hour), false},
+		{"0s", time.Time{}, true},
+		{"-5m", time.Time{}, true},
+		{"soon", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.length, func(t *testing.T) {
+			got, err := getExpiry(start, tt.length)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getExpiry() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("getExpiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
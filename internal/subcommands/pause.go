@@ -0,0 +1,90 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// PauseCmd is the flagset for the 'pause' subcommand
+var PauseCmd flag.FlagSet
+
+func init() {
+	PauseCmd = *flag.NewFlagSet("pause", flag.ExitOnError)
+	PauseCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	PauseCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	PauseCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	PauseCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	PauseCmd.BoolVar(&globalMode, "global", false, "\nPause the global pairing")
+	PauseCmd.BoolVar(&globalMode, "g", false, "\nPause the global pairing (shorthand)")
+	PauseCmd.StringVar(&dirPath, "dir", "", "\nPause the pairing for every repo in this directory")
+	oldUsage := PauseCmd.Usage
+	PauseCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'pause' subcommand stops adding co-authors to your commits, but remembers who you are pairing with.")
+		fmt.Println("Use it for a quick solo fix, then run 'gpair resume' to pick up where you left off.")
+		fmt.Println()
+		oldUsage()
+		PauseCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// Pause is the function executed by the 'pause' subcommand
+// It suspends the current pairing without forgetting it
+func Pause() {
+	err := PauseCmd.Parse(os.Args[2:])
+	if err != nil {
+		panic(err)
+	}
+
+	if internal.Help {
+		PauseCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	err = useDirFlag(globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	sessions, err := session.NewManager(getSessionKey(globalMode))
+	if err != nil {
+		panic(err)
+	}
+
+	current, err := sessions.Get()
+	if err != nil {
+		panic(err)
+	}
+
+	if current.IsEmpty() {
+		fmt.Println("You are not pairing, so there is nothing to pause")
+		os.Exit(0)
+	}
+
+	if current.Paused {
+		fmt.Println("Your pairing is already paused. Run 'gpair resume' to resume it.")
+		os.Exit(0)
+	}
+
+	err = pauseSession(sessions, current, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	recordEvent(history.Pause, nil, current, globalMode)
+
+	fmt.Printf("Paused pairing with '%s'. Run 'gpair resume' to resume it.\n", strings.Join(current.Aliases, "', '"))
+}
@@ -0,0 +1,109 @@
+package subcommands
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// enterTestRepo creates a git repo in a temporary directory and changes into it, with a home directory of its own
+// so that neither the user's global git config nor their gpair state is read or written, and a git identity to commit with.
+// The returned function undoes all of it.
+func enterTestRepo(t *testing.T) (string, func()) {
+	t.Helper()
+
+	tempDir, err := ioutil.TempDir("", "gpair_repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The temporary directory may be behind a symlink, as on macOS, and git reports resolved paths
+	tempDir, err = filepath.EvalSymlinks(tempDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	home := filepath.Join(tempDir, "home")
+	repo := filepath.Join(tempDir, "repo")
+	for _, dir := range []string{home, repo} {
+		if err := os.Mkdir(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := map[string]string{"HOME": home, "XDG_CONFIG_HOME": filepath.Join(home, ".config"), "GIT_CONFIG_NOSYSTEM": "1"}
+	previous := make(map[string]*string)
+	for key, value := range env {
+		if old, ok := os.LookupEnv(key); ok {
+			previous[key] = &old
+		} else {
+			previous[key] = nil
+		}
+		os.Setenv(key, value)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cleanup := func() {
+		os.Chdir(wd)
+		for key, old := range previous {
+			if old == nil {
+				os.Unsetenv(key)
+			} else {
+				os.Setenv(key, *old)
+			}
+		}
+		os.RemoveAll(tempDir)
+	}
+
+	for _, args := range [][]string{
+		{"init", "-q", repo},
+		{"config", "--global", "user.name", "Me"},
+		{"config", "--global", "user.email", "me@example.com"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			cleanup()
+			t.Fatalf("git %v failed: %v %s", args, err, out)
+		}
+	}
+
+	if err := os.Chdir(repo); err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+
+	return repo, cleanup
+}
+
+// runGit runs git in the current directory, failing the test if it fails
+func runGit(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v %s", args, err, out)
+	}
+
+	return string(out)
+}
+
+// commitFile commits a file with the given content and message in the current repo, and returns the SHA of the commit
+func commitFile(t *testing.T, name, content, message string) string {
+	t.Helper()
+
+	err := ioutil.WriteFile(name, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runGit(t, "add", name)
+	runGit(t, "commit", "-q", "-m", message)
+
+	return strings.TrimSpace(runGit(t, "rev-parse", "HEAD"))
+}
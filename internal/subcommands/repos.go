@@ -0,0 +1,259 @@
+package subcommands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// maxWorkers is the number of repos a command is applied to at the same time
+const maxWorkers = 8
+
+// noPromptEnv is set for the gpair processes that apply a command to each repo, which must not ask questions
+const noPromptEnv = "GPAIR_NO_PROMPT"
+
+// reposGlob is the value of the --repos flag, which applies a command to every repo matching a glob
+var reposGlob string
+
+// recurseSubmodules is the value of the --recurse-submodules flag, which also applies a command to submodules
+var recurseSubmodules bool
+
+// repoResult is the outcome of applying a command to one repo
+type repoResult struct {
+	repo    string
+	err     error
+	output  string
+	skipped bool
+}
+
+// isMultiRepo returns true if the command should be applied to several repos
+func isMultiRepo() bool {
+	return reposGlob != "" || recurseSubmodules
+}
+
+// runInRepos applies the current gpair command to every repo matched by --repos and --recurse-submodules,
+// by running gpair in each of them, and prints whether it succeeded in each one.
+// It exits if combined with --global or --dir, which do not apply to a repo.
+func runInRepos(global bool) {
+	if global || dirPath != "" {
+		fmt.Println("--repos and --recurse-submodules cannot be used with --global or --dir")
+		os.Exit(0)
+	}
+
+	repos, err := findRepos(reposGlob, recurseSubmodules)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(repos) == 0 {
+		fmt.Printf("No git repositories match '%s'\n", reposGlob)
+		os.Exit(0)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		panic(err)
+	}
+
+	args := stripRepoFlags(os.Args[1:])
+	internal.PrintVerbose("Running 'gpair %s' in %d repos", strings.Join(args, " "), len(repos))
+
+	printRepoResults("REPO", applyToRepos(repos, runCommand(executable, args)))
+}
+
+// runCommand returns a function that runs the given gpair executable with args in a repo.
+// gpair exits with a non-zero status if it fails in the repo, see exitWithError.
+func runCommand(executable string, args []string) func(repo string) (string, error) {
+	return func(repo string) (string, error) {
+		// gpair would only print a message and exit outside a repo, so check first
+		err := git.CheckRepo(repo)
+		if err != nil {
+			return "", err
+		}
+
+		cmd := exec.Command(executable, args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), noPromptEnv+"=1")
+
+		output, err := cmd.CombinedOutput()
+
+		return string(output), err
+	}
+}
+
+// exitWithError exits once gpair has printed why it could not do what it was asked.
+// Like the rest of gpair, it exits with status 0 when run directly, but with status 1 when run in each repo
+// by --repos or --everywhere, so that the repo is reported as failed.
+func exitWithError() {
+	if os.Getenv(noPromptEnv) != "" {
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// applyToRepos runs apply in each repo, with at most maxWorkers running at the same time,
+// and returns the results in the order of repos
+func applyToRepos(repos []string, apply func(repo string) (string, error)) []repoResult {
+	results := make([]repoResult, len(repos))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	workers := maxWorkers
+	if len(repos) < workers {
+		workers = len(repos)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				output, err := apply(repos[i])
+				results[i] = repoResult{repo: repos[i], err: err, output: output}
+			}
+		}()
+	}
+
+	for i := range repos {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// findRepos returns the top level of every git repo matching glob, or of the current repo if glob is empty,
+// along with their submodules if recurseSubmodules is true
+func findRepos(glob string, recurseSubmodules bool) ([]string, error) {
+	var repos []string
+	if glob == "" {
+		repoRoot, err := git.GetRepoRoot()
+		if err != nil {
+			fmt.Println("gpair must be run inside a git repository unless --repos is given")
+			exitWithError()
+		}
+		repos = append(repos, repoRoot)
+	} else {
+		pattern, err := resolveDir(glob)
+		if err != nil {
+			return nil, err
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, match := range matches {
+			// A repo has a .git directory, or a .git file if it is a worktree or submodule
+			if _, err := os.Stat(filepath.Join(match, ".git")); err == nil {
+				repos = append(repos, match)
+			}
+		}
+	}
+
+	if recurseSubmodules {
+		for _, repo := range repos {
+			submodules, err := git.GetSubmodules(repo)
+			if err != nil {
+				// The repo itself is reported as failed when the command is applied to it
+				internal.PrintVerbose("Failed to list the submodules of %s: %v", repo, err)
+				continue
+			}
+			repos = append(repos, submodules...)
+		}
+	}
+
+	sort.Strings(repos)
+
+	return repos, nil
+}
+
+// stripRepoFlags removes --repos and --recurse-submodules from the arguments of a gpair command,
+// so the command can be run in each repo
+func stripRepoFlags(args []string) []string {
+	var stripped []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		if !strings.HasPrefix(args[i], "-") || args[i] == "--" {
+			stripped = append(stripped, args[i])
+			continue
+		}
+
+		switch {
+		case name == "repos":
+			// Skip the value too
+			i++
+		case strings.HasPrefix(name, "repos="), name == "recurse-submodules", strings.HasPrefix(name, "recurse-submodules="):
+		default:
+			stripped = append(stripped, args[i])
+		}
+	}
+
+	return stripped
+}
+
+// printRepoResults prints a table of results, with the repo or scope of each under the given heading
+func printRepoResults(heading string, results []repoResult) {
+	failed := 0
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0x0)
+	fmt.Fprintf(tw, "%s\tRESULT\tDETAILS\n", heading)
+	for _, result := range results {
+		state := "ok"
+		if result.skipped {
+			state = "skipped"
+		} else if result.err != nil {
+			state = "failed"
+			failed++
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", result.repo, state, summarizeOutput(result.output, result.err))
+	}
+	tw.Flush()
+
+	if failed > 0 {
+		fmt.Printf("\nFailed in %d of %d repos\n", failed, len(results))
+	}
+}
+
+// summarizeOutput returns the line of the output of gpair in a repo that best explains what happened
+func summarizeOutput(output string, err error) string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, "panic: ") {
+			return strings.TrimPrefix(line, "panic: ")
+		}
+	}
+
+	if len(lines) > 0 {
+		return lines[len(lines)-1]
+	}
+
+	if err != nil {
+		return err.Error()
+	}
+
+	return ""
+}
+
+// repoFlagsUsage is the usage shared by the subcommands that accept --repos and --recurse-submodules
+func repoFlagsUsage() string {
+	return "With --repos GLOB, the command is run in every repo matching GLOB, such as '~/src/acme-*', and with\n" +
+		"--recurse-submodules in their submodules too. Quote GLOB so your shell does not expand it."
+}
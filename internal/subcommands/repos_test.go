@@ -0,0 +1,132 @@
+package subcommands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+func Test_stripRepoFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{"repos with value", []string{"al", "--repos", "~/src/*", "--for", "3h"}, []string{"al", "--for", "3h"}},
+		{"repos with equals", []string{"-repos=~/src/*", "al"}, []string{"al"}},
+		{"recurse submodules", []string{"solo", "--recurse-submodules", "-v"}, []string{"solo", "-v"}},
+		{"nothing to strip", []string{"al", "bo"}, []string{"al", "bo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripRepoFlags(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stripRepoFlags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_applyToRepos(t *testing.T) {
+	var repos []string
+	for i := 0; i < 3*maxWorkers; i++ {
+		repos = append(repos, string(rune('a'+i)))
+	}
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	results := applyToRepos(repos, func(repo string) (string, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		if repo == "b" {
+			return "", errors.New("failed")
+		}
+		return repo, nil
+	})
+
+	if maxRunning > maxWorkers {
+		t.Errorf("applyToRepos() ran %d at the same time, want at most %d", maxRunning, maxWorkers)
+	}
+
+	for i, result := range results {
+		if result.repo != repos[i] {
+			t.Errorf("applyToRepos() result %d is for %s, want %s", i, result.repo, repos[i])
+		}
+		if (result.err != nil) != (repos[i] == "b") {
+			t.Errorf("applyToRepos() result for %s has error %v", result.repo, result.err)
+		}
+	}
+}
+
+// Test_helperGpair stands in for gpair run in each repo by Test_runCommand. It fails like gpair does
+// on an invalid trailer in the repo named "broken", and succeeds elsewhere.
+func Test_helperGpair(t *testing.T) {
+	if os.Getenv("GPAIR_TEST_HELPER") == "" {
+		return
+	}
+
+	trailer := "Reviewed-by"
+	if wd, _ := os.Getwd(); filepath.Base(wd) == "broken" {
+		trailer = "Reviewed by"
+	}
+
+	_, err := setTrailers([]config.Collaborator{config.NewCollaborator("al", "Alice", "alice@example.com")}, map[string]string{"al": trailer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("Now pairing with 'al'")
+	os.Exit(0)
+}
+
+func Test_runCommand(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "gpair_repos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	var repos []string
+	for _, name := range []string{"api", "broken", "web"} {
+		repo := filepath.Join(tempDir, name)
+		if out, err := exec.Command("git", "init", "-q", repo).CombinedOutput(); err != nil {
+			t.Fatalf("git init failed: %v %s", err, out)
+		}
+		repos = append(repos, repo)
+	}
+
+	os.Setenv("GPAIR_TEST_HELPER", "1")
+	defer os.Unsetenv("GPAIR_TEST_HELPER")
+
+	results := applyToRepos(repos, runCommand(os.Args[0], []string{"-test.run=^Test_helperGpair$"}))
+
+	for _, result := range results {
+		wantFailed := filepath.Base(result.repo) == "broken"
+		if (result.err != nil) != wantFailed {
+			t.Errorf("runCommand() in %s error = %v, want failed %v", filepath.Base(result.repo), result.err, wantFailed)
+		}
+	}
+
+	if got := summarizeOutput(results[1].output, results[1].err); !strings.Contains(got, "is not a valid trailer") {
+		t.Errorf("runCommand() in broken output = %q, want the reason it failed", got)
+	}
+}
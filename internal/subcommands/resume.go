@@ -0,0 +1,86 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// ResumeCmd is the flagset for the 'resume' subcommand
+var ResumeCmd flag.FlagSet
+
+func init() {
+	ResumeCmd = *flag.NewFlagSet("resume", flag.ExitOnError)
+	ResumeCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	ResumeCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	ResumeCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	ResumeCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	ResumeCmd.BoolVar(&globalMode, "global", false, "\nResume the global pairing")
+	ResumeCmd.BoolVar(&globalMode, "g", false, "\nResume the global pairing (shorthand)")
+	ResumeCmd.StringVar(&dirPath, "dir", "", "\nResume the pairing for every repo in this directory")
+	oldUsage := ResumeCmd.Usage
+	ResumeCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'resume' subcommand resumes a pairing suspended with 'gpair pause'.")
+		fmt.Println()
+		oldUsage()
+		ResumeCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// Resume is the function executed by the 'resume' subcommand
+// It reinstates a paused pairing
+func Resume() {
+	err := ResumeCmd.Parse(os.Args[2:])
+	if err != nil {
+		panic(err)
+	}
+
+	if internal.Help {
+		ResumeCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	err = useDirFlag(globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	key := getSessionKey(globalMode)
+
+	sessions, err := session.NewManager(key)
+	if err != nil {
+		panic(err)
+	}
+
+	current, err := sessions.Get()
+	if err != nil {
+		panic(err)
+	}
+
+	if current.IsEmpty() || !current.Paused {
+		fmt.Println("There is no paused pairing to resume. Run 'gpair last' to pair with whoever you paired with last.")
+		os.Exit(0)
+	}
+
+	err = applySession(key, sessions, current, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	recordEvent(history.Resume, nil, current, globalMode)
+
+	fmt.Printf("Resumed pairing with '%s'\n", strings.Join(current.Aliases, "', '"))
+}
@@ -0,0 +1,378 @@
+package subcommands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
+	"github.com/adavidalbertson/gpair/internal/store"
+)
+
+// getSessionKey returns the key under which the pairing for the global scope or the current repo is saved.
+// It exits if gpair is not run inside a git repository and not in global mode.
+func getSessionKey(global bool) string {
+	key, err := lookupSessionKey(global)
+	if err != nil {
+		fmt.Println("gpair must be run inside a git repository unless in global mode")
+		exitWithError()
+	}
+
+	if global || dirScope != "" {
+		return key
+	}
+
+	repoName, err := git.GetRepoName()
+	if err == nil {
+		err = migrateRepoState(repoName, key)
+	}
+	if err != nil {
+		internal.PrintVerbose("Failed to move the pairing of this repo to %s: %v", key, err)
+	}
+
+	return key
+}
+
+// lookupSessionKey returns the key of the session of the global scope, the directory scope or the current repo,
+// without moving the state of a repo paired with an older version of gpair to it
+func lookupSessionKey(global bool) (string, error) {
+	if global {
+		return "gpair-global", nil
+	}
+
+	if dirScope != "" {
+		return git.GetDirKey(dirScope), nil
+	}
+
+	return git.GetRepoKey()
+}
+
+// loadSession returns the session saved under key.
+// Pairings made before gpair saved sessions are recovered from the template, using the roster to find aliases.
+func loadSession(sessions session.Manager, configurator config.Configurator, global bool) (session.Session, error) {
+	current, err := sessions.Get()
+	if err != nil || !current.IsEmpty() {
+		return current, err
+	}
+
+	pairingPath, err := git.GetScopedPairing(global)
+	if err != nil {
+		return current, err
+	}
+
+	if pairingPath == "" {
+		pairingPath, err = git.GetTemplate(global)
+		if err != nil {
+			return current, err
+		}
+	}
+
+	if pairingPath == "" || !git.IsGpairPath(pairingPath) {
+		return current, nil
+	}
+
+	templateBytes, err := ioutil.ReadFile(pairingPath)
+	if err != nil {
+		internal.PrintVerbose("Failed to read the current pairing from %s", pairingPath)
+		return current, nil
+	}
+
+	roster, err := configurator.GetCollaborators()
+	if err != nil {
+		return current, err
+	}
+
+	self, err := git.GetUser()
+	if err != nil {
+		return current, err
+	}
+
+	current = session.NewSession()
+	trailers := git.ExtractTrailers(string(templateBytes))
+	for _, trailer := range git.CoauthorTrailers(trailers, self) {
+		coauthor, ok := config.ParseCredit(trailer)
+		if !ok {
+			continue
+		}
+
+		if strings.EqualFold(coauthor.Trailer, git.CoDevelopedBy) {
+			current.Style = git.DCOStyle
+			coauthor.Trailer = ""
+		}
+
+		if collab, ok := config.FindByEmail(roster, coauthor.Email); ok {
+			coauthor.Alias = collab.Alias
+		} else {
+			coauthor.Alias = coauthor.Name
+		}
+		current.Add(coauthor)
+	}
+
+	return current, nil
+}
+
+// applySession points git at a template or pairing file for the session, depending on the backend, and saves it.
+// A paused session is resumed.
+func applySession(key string, sessions session.Manager, current session.Session, global bool) error {
+	current.Paused = false
+
+	backend, err := git.GetBackend()
+	if err != nil {
+		return err
+	}
+
+	data, err := git.NewTemplateData(global || dirScope != "", current.Style, current.Start, current.GetCoauthors()...)
+	if err != nil {
+		return err
+	}
+
+	if data.Style == git.DCOStyle && (data.Self.Name == "" || data.Self.Email == "") {
+		fmt.Println("Set git's user.name and user.email first, so that you can sign off your commits last.")
+		exitWithError()
+	}
+
+	var templatePath string
+	if backend == git.HookBackend {
+		templatePath, err = git.CreateTemplate(key, data)
+	} else {
+		templatePath, err = git.CreateComposedTemplate(key, global, data)
+	}
+	if err != nil {
+		if efi, ok := err.(*store.ErrFileInaccessible); ok {
+			fmt.Printf("Failed to create template file at %s. Make sure appropriate permissions are set.\n", efi.Path)
+			exitWithError()
+		}
+
+		if etf, ok := err.(*git.ErrTemplateFormat); ok {
+			fmt.Println(etf.Error())
+			exitWithError()
+		}
+
+		return err
+	}
+
+	if backend == git.HookBackend {
+		err = git.SetPairing(templatePath, global)
+	} else {
+		err = git.SetTemplate(templatePath, global)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = includeDirPairings(global)
+	if err != nil {
+		return err
+	}
+
+	for _, coauthor := range current.GetCoauthors() {
+		internal.PrintVerbose(coauthor.String())
+	}
+
+	registerPairing(key, global)
+
+	return saveSession(sessions, current)
+}
+
+// pauseSession stops adding the coauthors of the session to commits, but keeps the session so it can be resumed
+func pauseSession(sessions session.Manager, current session.Session, global bool) error {
+	err := git.UnsetPairing(global)
+	if err != nil {
+		return err
+	}
+
+	err = git.RestoreTemplate(global)
+	if err != nil {
+		return err
+	}
+
+	current.Paused = true
+
+	return saveSession(sessions, current)
+}
+
+// endSession stops pairing in the global scope or the current repo, and forgets the session.
+// A session bound to a branch is forgotten for that branch, along with the pairing for the whole repo it replaced.
+func endSession(sessions session.Manager, global bool) error {
+	err := unbindSession(sessions)
+	if err != nil {
+		return err
+	}
+
+	err = git.UnsetPairing(global)
+	if err != nil {
+		return err
+	}
+
+	err = git.RestoreTemplate(global)
+	if err != nil {
+		return err
+	}
+
+	if !global && dirScope != "" {
+		configPath, err := git.GetDirConfigPath(dirScope)
+		if err != nil {
+			return err
+		}
+
+		err = git.RemoveDirInclude(dirScope, configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	unregisterPairing(global)
+
+	return sessions.Clear()
+}
+
+// newSession starts a session with the collaborators, in the trailer style given by getTrailerStyle, which expires
+// after the length given with --for or gpair.maxSessionAge. It exits if either setting is not valid.
+func newSession(collaborators ...config.Collaborator) (session.Session, error) {
+	current := session.NewSession(collaborators...)
+
+	var err error
+	current.Style, err = getTrailerStyle()
+	if err != nil {
+		return current, err
+	}
+
+	current.Expires, err = getExpiry(current.Start, sessionLength)
+	if err != nil {
+		fmt.Println(err.Error())
+		exitWithError()
+	}
+
+	return current, nil
+}
+
+// getExpiry returns when a session starting at start should expire, after the given length or gpair.maxSessionAge.
+// It returns the zero time if the session should not expire.
+func getExpiry(start time.Time, length string) (time.Time, error) {
+	if length == "" {
+		var err error
+		length, err = git.GetMaxSessionAge()
+		if err != nil || length == "" {
+			return time.Time{}, err
+		}
+	}
+
+	duration, err := session.ParseDuration(length)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// A session that expires as soon as it starts would never credit anyone
+	if duration <= 0 {
+		return time.Time{}, fmt.Errorf("'%s' is not a session length, use a positive duration like '3h' or '2d'", length)
+	}
+
+	return start.Add(duration), nil
+}
+
+// ExpireSessions stops pairing in the current repo, the global scope and any directory if their sessions have expired.
+// It is run on every invocation, so that a forgotten pairing is not credited on later solo commits.
+func ExpireSessions() {
+	if !git.IsInstalled() {
+		return
+	}
+
+	expireSession(true, "globally")
+
+	if _, err := git.GetRepoRoot(); err == nil {
+		expireSession(false, "in this repo")
+	}
+
+	pairings, err := git.GetDirPairings()
+	if err != nil {
+		internal.PrintVerbose("Failed to check for expired directory pairings: %v", err)
+		return
+	}
+
+	for _, pairing := range pairings {
+		restore, err := enterDirScope(pairing.Dir)
+		if err != nil {
+			continue
+		}
+
+		expireSession(false, "in "+pairing.Dir)
+		restore()
+	}
+}
+
+// expireSession ends the session of the global scope or the current scope if it has expired.
+// Checking never creates any state, since it runs on every invocation, including from the commit hooks.
+func expireSession(global bool, scope string) {
+	key, err := lookupSessionKey(global)
+	if err != nil {
+		internal.PrintVerbose("Failed to check for an expired session: %v", err)
+		return
+	}
+
+	current, err := session.Peek(key)
+	if err != nil || current.IsEmpty() || !current.IsExpired(time.Now()) {
+		return
+	}
+
+	sessions, err := session.NewManager(getSessionKey(global))
+	if err != nil {
+		internal.PrintVerbose("Failed to end an expired session: %v", err)
+		return
+	}
+
+	err = endSession(sessions, global)
+	if err != nil {
+		internal.PrintVerbose("Failed to end an expired session: %v", err)
+		return
+	}
+
+	recordEvent(history.Solo, nil, session.Session{}, global)
+
+	fmt.Fprintf(os.Stderr, "gpair: your pairing with '%s' %s expired %s ago, so they are no longer added as co-authors.\n",
+		strings.Join(current.Aliases, "', '"), scope, time.Since(current.Expires).Round(time.Minute))
+}
+
+// recordEvent adds a change to the pairing in the global scope or the current repo to the history journal.
+// Failing to record history never stops gpair from pairing.
+func recordEvent(action string, aliases []string, current session.Session, global bool) {
+	event := history.Event{
+		Action:    action,
+		Scope:     "local",
+		Aliases:   aliases,
+		Coauthors: append([]string{}, current.Aliases...),
+	}
+
+	if global {
+		event.Scope = "global"
+	} else if dirScope != "" {
+		event.Scope = "dir"
+		event.Repo = dirScope
+	} else {
+		event.Repo, _ = git.GetRepoRoot()
+	}
+
+	journal, err := history.NewJournal()
+	if err == nil {
+		err = journal.Record(event)
+	}
+
+	if err != nil {
+		internal.PrintVerbose("Failed to record pairing history: %v", err)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,299 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/registry"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// SessionsCmd is the flagset for the 'sessions' subcommand
+var SessionsCmd flag.FlagSet
+
+func init() {
+	SessionsCmd = *flag.NewFlagSet("sessions", flag.ExitOnError)
+	SessionsCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	SessionsCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	SessionsCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	SessionsCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	oldUsage := SessionsCmd.Usage
+	SessionsCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'sessions' subcommand lists every repo, worktree, directory and global scope where gpair is pairing.")
+		fmt.Println("They are recorded in ~/.gpair/registry.jsonl whenever gpair sets a commit template or pairing.")
+		fmt.Println("Run 'gpair solo --everywhere' to stop pairing in all of them.")
+		fmt.Println()
+		oldUsage()
+		SessionsCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// Sessions is the function executed by the 'sessions' subcommand
+// It prints every scope where gpair is pairing
+func Sessions() {
+	err := SessionsCmd.Parse(os.Args[2:])
+	if err != nil || internal.Help {
+		SessionsCmd.Usage()
+		os.Exit(0)
+	}
+
+	reg, err := registry.NewRegistry()
+	if err != nil {
+		panic(err)
+	}
+
+	err = reg.Compact()
+	if err != nil {
+		panic(err)
+	}
+
+	entries, err := reg.List()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Not pairing anywhere")
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0x0)
+	fmt.Fprintln(tw, "SCOPE\tPATH\tCO-AUTHORS\tSINCE\tSTATE")
+	for _, entry := range entries {
+		path := entry.Path
+		if path == "" {
+			path = "-"
+		}
+
+		coauthors := "-"
+		state := "pairing"
+		sessions, err := session.NewManager(entry.Key)
+		if err != nil {
+			panic(err)
+		}
+
+		current, err := sessions.Get()
+		if err != nil {
+			panic(err)
+		}
+
+		if !current.IsEmpty() {
+			coauthors = strings.Join(current.Aliases, ", ")
+		}
+
+		if current.Paused {
+			state = "paused"
+		}
+
+		if isDeleted(entry) {
+			state = "deleted"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", entry.Scope, path, coauthors, entry.Time.Format("2006-01-02 15:04"), state)
+	}
+	tw.Flush()
+}
+
+// registerPairing records the scope of the pairing saved under key, so 'gpair sessions' can list it.
+// Failing to record it never stops gpair from pairing.
+func registerPairing(key string, global bool) {
+	entry := getRegistryEntry(key, global)
+
+	reg, err := registry.NewRegistry()
+	if err != nil {
+		internal.PrintVerbose("Failed to record the pairing in the registry: %v", err)
+		return
+	}
+
+	entries, err := reg.List()
+	if err == nil {
+		for _, registered := range entries {
+			if registered.Key == entry.Key && registered.Path == entry.Path {
+				return
+			}
+		}
+
+		err = reg.Add(entry)
+	}
+
+	if err != nil {
+		internal.PrintVerbose("Failed to record the pairing in the registry: %v", err)
+	}
+}
+
+// unregisterPairing removes the scope of the pairing in the global scope, the current directory or repo from the registry
+func unregisterPairing(global bool) {
+	key := getSessionKey(global)
+	entry := getRegistryEntry(key, global)
+
+	reg, err := registry.NewRegistry()
+	if err == nil {
+		err = reg.Remove(entry.Key, entry.Path)
+	}
+
+	if err != nil {
+		internal.PrintVerbose("Failed to remove the pairing from the registry: %v", err)
+	}
+}
+
+// getRegistryEntry returns the registry entry for the pairing saved under key in the current scope
+func getRegistryEntry(key string, global bool) registry.Entry {
+	entry := registry.Entry{Key: key, Scope: registry.Global}
+	if global {
+		return entry
+	}
+
+	if dirScope != "" {
+		entry.Scope = registry.Dir
+		entry.Path = dirScope
+		return entry
+	}
+
+	entry.Scope = registry.Local
+	if git.IsWorktreeScope() {
+		entry.Scope = registry.Worktree
+	}
+	entry.Path, _ = git.GetRepoRoot()
+
+	return entry
+}
+
+// isDeleted returns true if the entry is for a repo that no longer exists.
+// A directory does not need to exist for its pairing to be removed from the global config.
+func isDeleted(entry registry.Entry) bool {
+	if entry.Scope != registry.Local && entry.Scope != registry.Worktree {
+		return false
+	}
+
+	_, err := os.Stat(entry.Path)
+
+	return os.IsNotExist(err)
+}
+
+// soloEverywhere stops pairing in every scope in the registry, and prints whether it succeeded in each one.
+// Repos that have been deleted are skipped and forgotten.
+func soloEverywhere() {
+	if globalMode || dirPath != "" || isMultiRepo() {
+		fmt.Println("--everywhere cannot be used with --global, --dir, --repos or --recurse-submodules")
+		os.Exit(0)
+	}
+
+	reg, err := registry.NewRegistry()
+	if err != nil {
+		panic(err)
+	}
+
+	entries, err := reg.List()
+	if err != nil {
+		panic(err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Not pairing anywhere")
+		return
+	}
+
+	// The global and directory pairings are all in the global git config, so they are removed one at a time
+	var results []repoResult
+	var repos []string
+	repoEntries := make(map[string]registry.Entry)
+	for _, entry := range entries {
+		switch {
+		case entry.Scope == registry.Global:
+			results = append(results, repoResult{repo: "global", err: soloScope(true, "")})
+
+		case entry.Scope == registry.Dir:
+			results = append(results, repoResult{repo: "dir " + entry.Path, err: soloScope(false, entry.Path)})
+
+		case isDeleted(entry):
+			err := reg.Remove(entry.Key, entry.Path)
+			results = append(results, repoResult{repo: entry.Path, err: err, output: "no longer exists", skipped: err == nil})
+
+		default:
+			repos = append(repos, entry.Path)
+			repoEntries[entry.Path] = entry
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		panic(err)
+	}
+
+	args := []string{SoloCmd.Name()}
+	if uninstallHooksMode {
+		args = append(args, "--uninstall-hooks")
+	}
+
+	repoResults := applyToRepos(repos, func(repo string) (string, error) {
+		err := git.CheckRepo(repo)
+		if err != nil {
+			return "", err
+		}
+
+		cmd := exec.Command(executable, args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), noPromptEnv+"=1")
+
+		output, err := cmd.CombinedOutput()
+
+		return string(output), err
+	})
+
+	for _, result := range repoResults {
+		// gpair removes the repo from the registry itself, unless the key of the repo has changed since
+		if result.err == nil {
+			entry := repoEntries[result.repo]
+			err = reg.Remove(entry.Key, entry.Path)
+			if err != nil {
+				internal.PrintVerbose("Failed to remove %s from the registry: %v", entry.Path, err)
+			}
+		}
+	}
+	results = append(results, repoResults...)
+
+	err = reg.Compact()
+	if err != nil {
+		internal.PrintVerbose("Failed to compact the registry: %v", err)
+	}
+
+	printRepoResults("SCOPE", results)
+}
+
+// soloScope stops pairing globally, or for every repo in dir
+func soloScope(global bool, dir string) error {
+	if dir != "" {
+		restore, err := enterDirScope(dir)
+		if err != nil {
+			return err
+		}
+		defer restore()
+	}
+
+	sessions, err := session.NewManager(getSessionKey(global))
+	if err != nil {
+		return err
+	}
+
+	err = endSession(sessions, global)
+	if err != nil {
+		return err
+	}
+
+	recordEvent(history.Solo, nil, session.Session{}, global)
+
+	if global && uninstallHooksMode {
+		return uninstallHooks(true)
+	}
+
+	return nil
+}
@@ -3,6 +3,8 @@ package subcommands
 import (
 	"os"
 	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/history"
+	"github.com/adavidalbertson/gpair/internal/session"
 	"fmt"
 	"github.com/adavidalbertson/gpair/internal"
 	"flag"
@@ -11,6 +13,10 @@ import (
 // SoloCmd is the flagset for the 'unpair' subcommand
 var SoloCmd flag.FlagSet
 
+var uninstallHooksMode bool
+
+var everywhereMode bool
+
 func init() {
 	SoloCmd = *flag.NewFlagSet("solo", flag.ExitOnError)
 	SoloCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
@@ -19,11 +25,18 @@ func init() {
 	SoloCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
 	SoloCmd.BoolVar(&globalMode, "global", false, "\nSolo in global mode")
 	SoloCmd.BoolVar(&globalMode, "g", false, "\nSolo in global mode (shorthand)")
+	SoloCmd.StringVar(&reposGlob, "repos", "", "\nStop pairing in every repo matching this glob, such as '~/src/acme-*'")
+	SoloCmd.BoolVar(&recurseSubmodules, "recurse-submodules", false, "\nAlso stop pairing in the submodules of the repo, or of every repo matching --repos")
+	SoloCmd.StringVar(&dirPath, "dir", "", "\nStop pairing for every repo in this directory")
+	SoloCmd.BoolVar(&everywhereMode, "everywhere", false, "\nStop pairing in every repo, directory and global scope listed by 'gpair sessions'")
+	SoloCmd.BoolVar(&uninstallHooksMode, "uninstall-hooks", false, "\nAlso remove the gpair hooks and switch back to the template backend")
 	oldUsage := SoloCmd.Usage
 	SoloCmd.Usage = func() {
 		fmt.Println()
 		fmt.Println("The 'solo' subcommand removes co-author lines from the default commit message.")
 		fmt.Println("Use this subcommand when you are done pairing.")
+		fmt.Println(repoFlagsUsage())
+		fmt.Println("With --everywhere, it stops pairing everywhere 'gpair sessions' lists, skipping repos that have been deleted.")
 		fmt.Println()
 		oldUsage()
 		SoloCmd.PrintDefaults()
@@ -49,29 +62,40 @@ func Solo() {
 		os.Exit(0)
 	}
 
-	isCustomTemplate, err := git.IsCustomTemplate()
-	if err != nil {
-		panic(err)
+	if everywhereMode {
+		soloEverywhere()
+		return
 	}
 
-	if isCustomTemplate {
-		fmt.Println("It looks like you are using a custom git commit template already.")
-		os.Exit(0)
+	if isMultiRepo() {
+		runInRepos(globalMode)
+		return
 	}
 
-	if !globalMode {
-	_, err = git.GetRepoName()
-		if err != nil {
-			fmt.Println("gpair must be run inside a git repository unless in global mode")
-			os.Exit(0)
-		}
+	err = useDirFlag(globalMode)
+	if err != nil {
+		panic(err)
 	}
 
-	err = git.UnsetTemplate(globalMode)
+	sessions, err := session.NewManager(getSessionKey(globalMode))
 	if err != nil {
 		panic(err)
 	}
 
+	err = endSession(sessions, globalMode)
+	if err != nil {
+		panic(err)
+	}
+
+	recordEvent(history.Solo, nil, session.Session{}, globalMode)
+
+	if uninstallHooksMode {
+		err = uninstallHooks(globalMode)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	internal.PrintVerbose("Successfully unpaired!")
 
 	if globalMode {
@@ -0,0 +1,213 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// SquashMessageCmd is the flagset for the 'squash-message' subcommand
+var SquashMessageCmd flag.FlagSet
+
+var writeMode bool
+
+func init() {
+	SquashMessageCmd = *flag.NewFlagSet("squash-message", flag.ExitOnError)
+	SquashMessageCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	SquashMessageCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	SquashMessageCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	SquashMessageCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	SquashMessageCmd.BoolVar(&writeMode, "write", false, "\nAdd the trailers to .git/SQUASH_MSG, which 'git commit' uses after 'git merge --squash', instead of printing them")
+	SquashMessageCmd.BoolVar(&dcoMode, "dco", false, "\nCredit each coauthor with Co-developed-by and Signed-off-by, and sign off yourself last")
+	oldUsage := SquashMessageCmd.Usage
+	SquashMessageCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'squash-message' subcommand prints the trailers that credit everyone who worked on the commits in a range,")
+		fmt.Println("for the message of a squash merge: 'gpair squash-message BASE..HEAD'.")
+		fmt.Println("It collects the authors and co-authors of the commits, merges the identities of the same person using")
+		fmt.Println("your collaborators and the repo's .mailmap, and leaves you out, since you commit the squash.")
+		fmt.Println()
+		oldUsage()
+		SquashMessageCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// SquashMessage is the function executed by the 'squash-message' subcommand
+// It prints the trailers crediting the authors and coauthors of the commits in a range, or adds them to SQUASH_MSG
+func SquashMessage() {
+	args, err := parseInterspersed(&SquashMessageCmd, os.Args[2:])
+	if err != nil {
+		panic(err)
+	}
+
+	if internal.Help || len(args) != 1 {
+		SquashMessageCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	_, err = git.GetRepoRoot()
+	if err != nil {
+		fmt.Println("gpair must be run inside a git repository to collect the coauthors of commits")
+		os.Exit(0)
+	}
+
+	commits, err := git.GetCommits(args[0])
+	if err != nil {
+		if err, ok := err.(*git.ErrRevisionRange); ok {
+			fmt.Println(err.Error())
+			os.Exit(0)
+		}
+
+		panic(err)
+	}
+
+	configurator, err := config.NewConfigurator()
+	if err != nil {
+		panic(err)
+	}
+
+	roster, err := configurator.GetCollaborators()
+	if err != nil {
+		panic(err)
+	}
+
+	self, err := git.GetUser()
+	if err != nil {
+		panic(err)
+	}
+
+	identities := getIdentities(commits)
+
+	// Map everyone through .mailmap at once, including yourself
+	mapped, err := git.MapIdentities(append(identities, self))
+	if err != nil {
+		panic(err)
+	}
+
+	coauthors := squashCoauthors(mapped[:len(identities)], roster, mapped[len(identities)])
+	if len(coauthors) == 0 {
+		fmt.Fprintf(os.Stderr, "Nobody but you worked on the commits in %s\n", args[0])
+		return
+	}
+
+	coauthors, err = setTrailers(coauthors, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	style, err := getTrailerStyle()
+	if err != nil {
+		panic(err)
+	}
+
+	trailers := git.GetTrailers(style, self, coauthors...)
+	if !writeMode {
+		fmt.Println(strings.Join(trailers, "\n"))
+		return
+	}
+
+	err = writeSquashMessage(trailers)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// getIdentities returns the authors and co-authors of the commits, oldest first, as they appear in the commits
+func getIdentities(commits []git.Commit) []config.Collaborator {
+	var identities []config.Collaborator
+	for i := len(commits) - 1; i >= 0; i-- {
+		identities = append(identities, getCommitIdentities(commits[i])...)
+	}
+
+	return identities
+}
+
+// getCommitIdentities returns the author of a commit followed by everyone its Co-authored-by
+// and Co-developed-by trailers credit, as they appear in the commit
+func getCommitIdentities(commit git.Commit) []config.Collaborator {
+	identities := []config.Collaborator{commit.Author}
+	for _, trailer := range git.ParseTrailers(commit.Message, git.NoComments) {
+		if !strings.EqualFold(trailer.Key, config.DefaultTrailer) && !strings.EqualFold(trailer.Key, git.CoDevelopedBy) {
+			continue
+		}
+
+		if coauthor, ok := config.ParseCredit(trailer.String()); ok {
+			identities = append(identities, coauthor)
+		}
+	}
+
+	return identities
+}
+
+// squashCoauthors returns each person among the identities once, in the order they first appear, leaving out self.
+// Identities with the email of a collaborator are that collaborator, and other identities are the same person
+// if they have the same email. The identities and self are expected to be mapped through .mailmap already.
+func squashCoauthors(identities []config.Collaborator, roster []config.Collaborator, self config.Collaborator) []config.Collaborator {
+	seen := map[string]bool{identityKey(self, roster): self.Email != ""}
+	var coauthors []config.Collaborator
+	for _, identity := range identities {
+		k := identityKey(identity, roster)
+		if identity.Email == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+
+		coauthor := config.Collaborator{Alias: identity.Name, Name: identity.Name, Email: identity.Email}
+		if collab, ok := config.FindByEmail(roster, identity.Email); ok {
+			coauthor = collab
+			coauthor.Trailer = ""
+		}
+		coauthors = append(coauthors, coauthor)
+	}
+
+	return coauthors
+}
+
+// identityKey returns what identifies the person with an identity: the alias of the collaborator with its email,
+// or else the email itself, ignoring case
+func identityKey(identity config.Collaborator, roster []config.Collaborator) string {
+	if collab, ok := config.FindByEmail(roster, identity.Email); ok {
+		return "alias:" + collab.Alias
+	}
+
+	return "email:" + strings.ToLower(identity.Email)
+}
+
+// writeSquashMessage adds the trailers to the squash message git uses for the next commit after 'git merge --squash'
+func writeSquashMessage(trailers []string) error {
+	path, err := git.GetGitPath("SQUASH_MSG")
+	if err != nil {
+		return err
+	}
+
+	message, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	commentChar, err := git.GetCommentChar(string(message))
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path, []byte(git.AppendTrailers(string(message), trailers, commentChar)), 0644)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Added %d trailers to %s\n", len(trailers), path)
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package subcommands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+func TestSquashCoauthors(t *testing.T) {
+	alice := config.NewCollaborator("alice", "Alice", "alice@example.com")
+	roster := []config.Collaborator{alice}
+	me := config.Collaborator{Name: "Me", Email: "me@example.com"}
+
+	identities := []config.Collaborator{
+		{Name: "Me", Email: "me@example.com"},
+		{Name: "Al", Email: "ALICE@example.com"},
+		{Name: "Bob", Email: "bob@example.com"},
+		{Name: "Alice Smith", Email: "alice@example.com"},
+		{Name: "Robert", Email: "Bob@example.com"},
+		{Name: "Nobody", Email: ""},
+		{Name: "Myself", Email: "ME@example.com"},
+	}
+
+	want := []config.Collaborator{
+		alice,
+		{Alias: "Bob", Name: "Bob", Email: "bob@example.com"},
+	}
+
+	if got := squashCoauthors(identities, roster, me); !reflect.DeepEqual(got, want) {
+		t.Errorf("squashCoauthors() = %v, want %v", got, want)
+	}
+}
+
+func TestGetCommitIdentities(t *testing.T) {
+	me := config.Collaborator{Name: "Me", Email: "me@example.com"}
+
+	// A committed message has no comments, so a line starting with the comment character is part of it
+	commit := git.Commit{
+		Author: me,
+		Message: "Fix the parser\n\n#123 was caused by a missing check.\n\n" +
+			"Reviewed-by: Carol <carol@example.com>\n" +
+			"Co-authored-by: Alice <alice@example.com>\n" +
+			"Co-developed-by: Bob <bob@example.com>\n" +
+			"Signed-off-by: Bob <bob@example.com>\n",
+	}
+
+	want := []config.Collaborator{
+		me,
+		{Name: "Alice", Email: "alice@example.com"},
+		{Name: "Bob", Email: "bob@example.com", Trailer: git.CoDevelopedBy},
+	}
+
+	if got := getCommitIdentities(commit); !reflect.DeepEqual(got, want) {
+		t.Errorf("getCommitIdentities() = %v, want %v", got, want)
+	}
+}
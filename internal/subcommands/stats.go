@@ -0,0 +1,301 @@
+package subcommands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// StatsCmd is the flagset for the 'stats' subcommand
+var StatsCmd flag.FlagSet
+
+var statsFormat string
+
+// csvFormat is the output format of the 'stats' subcommand for spreadsheets
+const csvFormat = "csv"
+
+func init() {
+	StatsCmd = *flag.NewFlagSet("stats", flag.ExitOnError)
+	StatsCmd.String("since", "", "Only count commits committed from this date on, as YYYY-MM-DD or a duration like '7d' or '12h'")
+	StatsCmd.String("until", "", "Only count commits committed before this date, as YYYY-MM-DD or a duration like '7d' or '12h'")
+	StatsCmd.StringVar(&statsFormat, "format", humanFormat, "The output format: 'human' for a pair stairs matrix, 'csv' or 'json'")
+	StatsCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	StatsCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	StatsCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	StatsCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	oldUsage := StatsCmd.Usage
+	StatsCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'stats' subcommand shows how often each pair of people committed together, as a pair stairs matrix:")
+		fmt.Println("'gpair stats [REVRANGE]', where the range defaults to HEAD. Merge commits are not counted.")
+		fmt.Println("Everyone a commit credits with Co-authored-by or Co-developed-by paired with its author and each other.")
+		fmt.Println("The diagonal counts the commits each person made alone. People are shown by alias if they are")
+		fmt.Println("your collaborators, and the identities of the same person are merged using the repo's .mailmap.")
+		fmt.Println("For example, 'gpair stats -since 30d' shows who paired with whom over the last month.")
+		fmt.Println()
+		oldUsage()
+		StatsCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// statsPerson is someone who made commits counted by the 'stats' subcommand
+type statsPerson struct {
+	Label string `json:"label"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// pairStats is how many commits each pair of people made together. Counts is symmetric,
+// and its diagonal is how many commits each person made alone.
+type pairStats struct {
+	Commits int           `json:"commits"`
+	People  []statsPerson `json:"people"`
+	Counts  [][]int       `json:"counts"`
+}
+
+// Stats is the function executed by the 'stats' subcommand
+// It prints how often each pair of people committed together in a range of commits
+func Stats() {
+	args, err := parseInterspersed(&StatsCmd, os.Args[2:])
+	if err != nil {
+		panic(err)
+	}
+
+	if internal.Help || len(args) > 1 {
+		StatsCmd.Usage()
+		os.Exit(0)
+	}
+
+	if statsFormat != humanFormat && statsFormat != csvFormat && statsFormat != jsonFormat {
+		fmt.Printf("Unknown format '%s', use 'human', 'csv' or 'json'\n", statsFormat)
+		os.Exit(0)
+	}
+
+	revRange := "HEAD"
+	if len(args) == 1 {
+		revRange = args[0]
+	}
+
+	logArgs := []string{"--no-merges"}
+	now := time.Now()
+	for _, name := range []string{"since", "until"} {
+		t, err := parseTime(StatsCmd.Lookup(name).Value.String(), now)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(0)
+		}
+
+		if !t.IsZero() {
+			logArgs = append(logArgs, fmt.Sprintf("--%s=%s", name, t.Format(time.RFC3339)))
+		}
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	_, err = git.GetRepoRoot()
+	if err != nil {
+		fmt.Println("gpair must be run inside a git repository to count pairs")
+		os.Exit(0)
+	}
+
+	commits, err := git.GetCommits(revRange, logArgs...)
+	if err != nil {
+		if err, ok := err.(*git.ErrRevisionRange); ok {
+			fmt.Println(err.Error())
+			os.Exit(0)
+		}
+
+		panic(err)
+	}
+
+	roster, err := getRoster()
+	if err != nil {
+		panic(err)
+	}
+
+	// Map everyone through .mailmap at once, then split them up by commit again
+	var identities []config.Collaborator
+	var sizes []int
+	for _, commit := range commits {
+		commitIdentities := getCommitIdentities(commit)
+		identities = append(identities, commitIdentities...)
+		sizes = append(sizes, len(commitIdentities))
+	}
+
+	mapped, err := git.MapIdentities(identities)
+	if err != nil {
+		panic(err)
+	}
+
+	var byCommit [][]config.Collaborator
+	for _, size := range sizes {
+		byCommit = append(byCommit, mapped[:size])
+		mapped = mapped[size:]
+	}
+
+	stats := countPairs(byCommit, roster)
+
+	switch statsFormat {
+	case jsonFormat:
+		jsonBytes, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Println(string(jsonBytes))
+
+	case csvFormat:
+		err = writeStatsCSV(os.Stdout, stats)
+		if err != nil {
+			panic(err)
+		}
+
+	default:
+		if stats.Commits == 0 {
+			fmt.Printf("There are no commits to count in %s\n", revRange)
+			return
+		}
+
+		writePairStairs(os.Stdout, stats)
+		fmt.Printf("\nCounted %d commits. The diagonal is the commits each person made alone.\n", stats.Commits)
+	}
+}
+
+// countPairs counts the commits each pair of people made together, given the author and co-authors of each commit.
+// Identities with the email of a collaborator are that collaborator, and other identities are the same person
+// if they have the same email. The identities are expected to be mapped through .mailmap already.
+// People are sorted by label, which is the alias of a collaborator, or else the name first seen.
+func countPairs(commits [][]config.Collaborator, roster []config.Collaborator) pairStats {
+	index := make(map[string]int)
+	var people []statsPerson
+	var pairs [][2]int
+	var solos []int
+
+	for _, identities := range commits {
+		var present []int
+		inCommit := make(map[int]bool)
+		for _, identity := range identities {
+			if identity.Email == "" {
+				continue
+			}
+
+			k := identityKey(identity, roster)
+			i, ok := index[k]
+			if !ok {
+				person := statsPerson{Label: identity.Name, Name: identity.Name, Email: identity.Email}
+				if collab, ok := config.FindByEmail(roster, identity.Email); ok {
+					person = statsPerson{Label: collab.Alias, Name: collab.Name, Email: collab.Email}
+				}
+				if person.Label == "" {
+					person.Label = person.Email
+				}
+
+				i = len(people)
+				index[k] = i
+				people = append(people, person)
+			}
+
+			if !inCommit[i] {
+				inCommit[i] = true
+				present = append(present, i)
+			}
+		}
+
+		if len(present) == 1 {
+			solos = append(solos, present[0])
+		}
+
+		for a := 0; a < len(present); a++ {
+			for b := a + 1; b < len(present); b++ {
+				pairs = append(pairs, [2]int{present[a], present[b]})
+			}
+		}
+	}
+
+	// Sort the people, then place the counts by where each person ended up
+	order := make([]int, len(people))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return strings.ToLower(people[order[i]].Label) < strings.ToLower(people[order[j]].Label)
+	})
+
+	position := make([]int, len(people))
+	stats := pairStats{Commits: len(commits), People: make([]statsPerson, len(people)), Counts: make([][]int, len(people))}
+	for pos, i := range order {
+		position[i] = pos
+		stats.People[pos] = people[i]
+		stats.Counts[pos] = make([]int, len(people))
+	}
+
+	for _, i := range solos {
+		stats.Counts[position[i]][position[i]]++
+	}
+
+	for _, pair := range pairs {
+		a, b := position[pair[0]], position[pair[1]]
+		stats.Counts[a][b]++
+		stats.Counts[b][a]++
+	}
+
+	return stats
+}
+
+// writePairStairs writes the counts as a pair stairs matrix, with each person's row ending on the diagonal
+func writePairStairs(w io.Writer, stats pairStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0x0)
+	for i, person := range stats.People {
+		row := []string{person.Label}
+		for j := 0; j <= i; j++ {
+			row = append(row, strconv.Itoa(stats.Counts[i][j]))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	header := []string{""}
+	for _, person := range stats.People {
+		header = append(header, person.Label)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	tw.Flush()
+}
+
+// writeStatsCSV writes the full matrix of counts as CSV, with the labels of the people as the header row and first column
+func writeStatsCSV(w io.Writer, stats pairStats) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{""}
+	for _, person := range stats.People {
+		header = append(header, person.Label)
+	}
+	cw.Write(header)
+
+	for i, person := range stats.People {
+		row := []string{person.Label}
+		for _, count := range stats.Counts[i] {
+			row = append(row, strconv.Itoa(count))
+		}
+		cw.Write(row)
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
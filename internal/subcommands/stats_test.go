@@ -0,0 +1,69 @@
+package subcommands
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+func TestCountPairs(t *testing.T) {
+	alice := config.NewCollaborator("alice", "Alice", "alice@example.com")
+	roster := []config.Collaborator{alice}
+
+	al := config.Collaborator{Name: "Al", Email: "ALICE@example.com"}
+	bob := config.Collaborator{Name: "Bob", Email: "bob@example.com"}
+	carol := config.Collaborator{Name: "Carol", Email: "carol@example.com"}
+
+	commits := [][]config.Collaborator{
+		{bob},
+		{bob, al},
+		{al, bob},
+		{carol, alice, bob},
+		{alice, {Name: "Nobody", Email: ""}},
+		{bob, {Name: "Robert", Email: "Bob@example.com"}},
+	}
+
+	want := pairStats{
+		Commits: 6,
+		People: []statsPerson{
+			{Label: "alice", Name: "Alice", Email: "alice@example.com"},
+			{Label: "Bob", Name: "Bob", Email: "bob@example.com"},
+			{Label: "Carol", Name: "Carol", Email: "carol@example.com"},
+		},
+		Counts: [][]int{
+			{1, 3, 1},
+			{3, 2, 1},
+			{1, 1, 0},
+		},
+	}
+
+	if got := countPairs(commits, roster); !reflect.DeepEqual(got, want) {
+		t.Errorf("countPairs() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	stats := pairStats{
+		Commits: 5,
+		People:  []statsPerson{{Label: "alice"}, {Label: "bob"}},
+		Counts:  [][]int{{1, 3}, {3, 1}},
+	}
+
+	var out bytes.Buffer
+	writePairStairs(&out, stats)
+	want := "alice  1\nbob    3      1\n       alice  bob\n"
+	if out.String() != want {
+		t.Errorf("writePairStairs() wrote %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := writeStatsCSV(&out, stats); err != nil {
+		t.Fatal(err)
+	}
+	want = ",alice,bob\nalice,1,3\nbob,3,1\n"
+	if out.String() != want {
+		t.Errorf("writeStatsCSV() wrote %q, want %q", out.String(), want)
+	}
+}
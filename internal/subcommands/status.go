@@ -0,0 +1,432 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// StatusCmd is the flagset for the 'status' subcommand
+var StatusCmd flag.FlagSet
+
+func init() {
+	StatusCmd = *flag.NewFlagSet("status", flag.ExitOnError)
+	StatusCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	StatusCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	StatusCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	StatusCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	oldUsage := StatusCmd.Usage
+	StatusCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'status' subcommand shows who you are pairing with in the current repo.")
+		fmt.Println("It shows both the local and global pairing, and which one git will use.")
+		fmt.Println("If each worktree of the repo has its own pairing, the local pairing is shown as the worktree pairing.")
+		fmt.Println("Pairings for directories made with 'gpair --dir PATH' are shown between the local and global pairing.")
+		fmt.Println("Pairings bound to branches with 'gpair --branch' are listed too, with the current branch marked by '*'.")
+		fmt.Println()
+		oldUsage()
+		StatusCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// Warnings shown below a pairing by the 'status' subcommand
+const (
+	missingWarning = "Warning: this file no longer exists. Run gpair again to recreate it, or 'gpair solo' to stop pairing."
+	staleWarning   = "Warning: this pairing is stale because your collaborators have changed since. Run gpair again to refresh it."
+)
+
+// pairingStatus describes the pairing configured in one git config scope
+type pairingStatus struct {
+	scope     string
+	path      string
+	isGpair   bool
+	missing   bool
+	since     time.Time
+	expires   time.Time
+	coauthors []coauthorStatus
+	paused    []string
+	effective bool
+	inactive  bool
+	// state and warning are set by resolveStatuses
+	state   string
+	warning string
+}
+
+// coauthorStatus is a co-author credited by a pairing. The alias is set if they are among the collaborators,
+// and the note if they are not, or if they are saved under another name since.
+type coauthorStatus struct {
+	config.Collaborator
+	note string
+}
+
+func getPairingStatus(backend string, global bool) (pairingStatus, error) {
+	status := pairingStatus{scope: "local"}
+	if global {
+		status.scope = "global"
+	} else if dirScope != "" {
+		status.scope = "dir " + dirScope
+	} else if git.IsWorktreeScope() {
+		status.scope = "worktree"
+	}
+
+	var err error
+	if backend == git.HookBackend {
+		status.path, err = git.GetScopedPairing(global)
+	} else {
+		status.path, err = git.GetTemplate(global)
+	}
+	if err != nil {
+		return status, err
+	}
+
+	// status only reads the session, so it neither creates state nor moves the state of a repo paired by an older gpair
+	key, err := lookupSessionKey(global)
+	if err != nil {
+		return status, err
+	}
+
+	current, err := session.Peek(key)
+	if err != nil {
+		return status, err
+	}
+
+	if status.path == "" {
+		if current.Paused {
+			status.paused = current.Aliases
+		}
+
+		return status, nil
+	}
+
+	status.isGpair = git.IsGpairPath(status.path)
+	if !status.isGpair {
+		return status, nil
+	}
+
+	stats, err := os.Stat(status.path)
+	if os.IsNotExist(err) {
+		status.missing = true
+		return status, nil
+	} else if err != nil {
+		return status, err
+	}
+	status.since = stats.ModTime()
+
+	// Prefer the start of the saved session, since the template is rewritten when people join or leave
+	if !current.IsEmpty() {
+		status.since = current.Start
+		status.expires = current.Expires
+	}
+
+	templateBytes, err := ioutil.ReadFile(status.path)
+	if err != nil {
+		return status, err
+	}
+
+	self, err := git.GetUser()
+	if err != nil {
+		return status, err
+	}
+
+	for _, trailer := range git.CoauthorTrailers(git.ExtractTrailers(string(templateBytes)), self) {
+		if coauthor, ok := config.ParseCredit(trailer); ok {
+			status.coauthors = append(status.coauthors, coauthorStatus{Collaborator: coauthor})
+		}
+	}
+
+	return status, nil
+}
+
+// Status is the function executed by the 'status' subcommand
+// It prints the pairing in effect for the current repo
+func Status() {
+	err := StatusCmd.Parse(os.Args[2:])
+	if err != nil || internal.Help {
+		StatusCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	configurator, err := config.NewConfigurator()
+	if err != nil {
+		panic(err)
+	}
+
+	roster, err := configurator.GetCollaborators()
+	if err != nil {
+		panic(err)
+	}
+
+	backend, err := git.GetBackend()
+	if err != nil {
+		panic(err)
+	}
+
+	var statuses []pairingStatus
+	var branches map[string]session.Session
+	_, err = git.GetRepoRoot()
+	inRepo := err == nil
+	if inRepo {
+		local, err := getPairingStatus(backend, false)
+		if err != nil {
+			panic(err)
+		}
+		statuses = append(statuses, local)
+
+		branches, err = peekBranchSessions()
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		internal.PrintVerbose("Not in a git repository, so no pairing for a repo or directory applies")
+	}
+
+	dirStatuses, err := getDirPairingStatuses(backend, inRepo)
+	if err != nil {
+		panic(err)
+	}
+	statuses = append(statuses, dirStatuses...)
+
+	global, err := getPairingStatus(backend, true)
+	if err != nil {
+		panic(err)
+	}
+	statuses = append(statuses, global)
+
+	var effectivePath string
+	if backend == git.HookBackend {
+		effectivePath, err = git.GetPairing()
+	} else {
+		effectivePath, err = git.GetEffectiveTemplate()
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	shadowing := resolveStatuses(statuses, effectivePath, roster)
+	printStatus(backend, statuses, shadowing)
+
+	if len(branches) > 0 {
+		// The pairing for the whole repo, set aside while on a branch with its own, is not a branch pairing
+		delete(branches, repoPairing)
+	}
+
+	if len(branches) > 0 {
+		branch, err := git.GetCurrentBranch()
+		if err != nil {
+			panic(err)
+		}
+
+		printBranches(branches, branch)
+	}
+}
+
+// soloFlags returns the flags to pass to 'gpair solo' to stop the pairing in the given scope
+func soloFlags(scope string) string {
+	if strings.HasPrefix(scope, "dir ") {
+		return " --dir " + strings.TrimPrefix(scope, "dir ")
+	}
+
+	return ""
+}
+
+func printBranches(branches map[string]session.Session, currentBranch string) {
+	var names []string
+	for name := range branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println()
+	fmt.Println("Branch pairings:")
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0x0)
+	for _, name := range names {
+		marker := " "
+		if name == currentBranch {
+			marker = "*"
+		}
+
+		fmt.Fprintf(tw, "  %s %s\t%s", marker, name, strings.Join(branches[name].Aliases, ", "))
+		if branches[name].Paused {
+			fmt.Fprint(tw, "\t(paused)")
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+// getDirPairingStatuses returns the status of the pairings for directories. In a repo, only those for directories
+// containing it are returned, in the order git includes them. Outside a repo, they are all returned as inactive.
+func getDirPairingStatuses(backend string, inRepo bool) ([]pairingStatus, error) {
+	pairings, err := git.GetDirPairings()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []pairingStatus
+	// Later includes take precedence, and statuses are listed from the highest precedence down
+	for i := len(pairings) - 1; i >= 0; i-- {
+		applies := false
+		if inRepo {
+			applies, err = git.IsInDir(pairings[i].Dir)
+			if err != nil {
+				return nil, err
+			}
+
+			if !applies {
+				continue
+			}
+		}
+
+		restore, err := enterDirScope(pairings[i].Dir)
+		if err != nil {
+			return nil, err
+		}
+
+		status, err := getPairingStatus(backend, false)
+		restore()
+		if err != nil {
+			return nil, err
+		}
+
+		status.inactive = !applies
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// resolveStatuses marks the pairing git uses, the one at effectivePath, as effective and the others as shadowed,
+// and checks the co-authors of each pairing against the roster, warning if the pairing is missing or stale.
+// It returns the pairing that shadows a global gpair pairing, or nil if there is none.
+func resolveStatuses(statuses []pairingStatus, effectivePath string, roster []config.Collaborator) *pairingStatus {
+	var effective *pairingStatus
+	for i := range statuses {
+		status := &statuses[i]
+		status.effective = status.path != "" && status.path == effectivePath
+		if status.effective && effective == nil {
+			effective = status
+		}
+
+		status.state = "shadowed"
+		if status.effective {
+			status.state = "effective"
+		} else if status.inactive {
+			status.state = "for repos in this directory"
+		}
+
+		if status.missing {
+			status.warning = missingWarning
+		}
+
+		for j := range status.coauthors {
+			coauthor := &status.coauthors[j]
+			if collab, ok := config.FindByEmail(roster, coauthor.Email); !ok {
+				coauthor.note = "(not in your collaborators)"
+				status.warning = staleWarning
+			} else {
+				coauthor.Alias = collab.Alias
+				if collab.Name != coauthor.Name {
+					coauthor.note = fmt.Sprintf("(now saved as %s)", collab.Name)
+					status.warning = staleWarning
+				}
+			}
+		}
+	}
+
+	if effective == nil || effective.scope == "global" {
+		return nil
+	}
+
+	for _, status := range statuses {
+		if status.scope == "global" && status.isGpair {
+			return effective
+		}
+	}
+
+	return nil
+}
+
+func printStatus(backend string, statuses []pairingStatus, shadowing *pairingStatus) {
+	fmt.Printf("Backend: %s\n", backend)
+
+	for _, status := range statuses {
+		fmt.Println()
+
+		if status.path == "" && len(status.paused) > 0 {
+			fmt.Printf("%s: paused pairing with '%s'. Run 'gpair resume' to resume it.\n", status.scope, strings.Join(status.paused, "', '"))
+			continue
+		}
+
+		if status.path == "" {
+			fmt.Printf("%s: not pairing\n", status.scope)
+			continue
+		}
+
+		fmt.Printf("%s (%s): %s\n", status.scope, status.state, status.path)
+
+		if !status.isGpair {
+			fmt.Println("  This is your own commit template, not a gpair pairing.")
+			continue
+		}
+
+		if status.missing {
+			fmt.Println("  " + status.warning)
+			continue
+		}
+
+		if len(status.coauthors) == 0 {
+			fmt.Println("  No co-authors")
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0x0)
+		for _, coauthor := range status.coauthors {
+			alias := coauthor.Alias
+			if alias == "" {
+				alias = "-"
+			}
+			fmt.Fprintf(tw, "  %s\t%s <%s>", alias, coauthor.Name, coauthor.Email)
+			if coauthor.Trailer != "" {
+				fmt.Fprintf(tw, "\t%s", coauthor.Trailer)
+			}
+			if coauthor.note != "" {
+				fmt.Fprintf(tw, "\t%s", coauthor.note)
+			}
+			fmt.Fprintln(tw)
+		}
+		tw.Flush()
+
+		fmt.Printf("  Since %s (%s ago)\n", status.since.Format("2006-01-02 15:04"), time.Since(status.since).Round(time.Minute))
+		if !status.expires.IsZero() {
+			fmt.Printf("  Until %s (in %s)\n", status.expires.Format("2006-01-02 15:04"), time.Until(status.expires).Round(time.Minute))
+		}
+
+		if status.warning != "" {
+			fmt.Println("  " + status.warning)
+		}
+	}
+
+	if shadowing != nil {
+		fmt.Println()
+		fmt.Printf("Warning: your global pairing is shadowed by the %s pairing, so it has no effect here.\n", shadowing.scope)
+		if shadowing.isGpair {
+			fmt.Printf("Run 'gpair solo%s' to use the global pairing in this repo.\n", soloFlags(shadowing.scope))
+		}
+	}
+}
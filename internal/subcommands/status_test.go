@@ -0,0 +1,177 @@
+package subcommands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+)
+
+func Test_resolveStatuses(t *testing.T) {
+	roster := []config.Collaborator{
+		{Alias: "al", Name: "Alice", Email: "alice@example.com"},
+		{Alias: "bo", Name: "Bob Smith", Email: "bob@example.com"},
+	}
+	alice := coauthorStatus{Collaborator: config.Collaborator{Name: "Alice", Email: "alice@example.com"}}
+	bob := coauthorStatus{Collaborator: config.Collaborator{Name: "Bob", Email: "bob@example.com"}}
+	carol := coauthorStatus{Collaborator: config.Collaborator{Name: "Carol", Email: "carol@example.com"}}
+
+	local := pairingStatus{scope: "local", path: "/home/.gpair/repo.txt", isGpair: true, coauthors: []coauthorStatus{alice}}
+	dir := pairingStatus{scope: "dir /src", path: "/home/.gpair/dir.txt", isGpair: true, coauthors: []coauthorStatus{alice}}
+	global := pairingStatus{scope: "global", path: "/home/.gpair/global.txt", isGpair: true, coauthors: []coauthorStatus{alice}}
+	notPairing := pairingStatus{scope: "global"}
+	ownTemplate := pairingStatus{scope: "local", path: "/home/template.txt"}
+	missing := pairingStatus{scope: "local", path: "/home/.gpair/missing.txt", isGpair: true, missing: true}
+	renamed := pairingStatus{scope: "local", path: "/home/.gpair/repo.txt", isGpair: true, coauthors: []coauthorStatus{bob}}
+	unknown := pairingStatus{scope: "local", path: "/home/.gpair/repo.txt", isGpair: true, coauthors: []coauthorStatus{alice, carol}}
+	inactive := pairingStatus{scope: "dir /other", path: "/home/.gpair/other.txt", isGpair: true, inactive: true}
+
+	tests := []struct {
+		name          string
+		statuses      []pairingStatus
+		effectivePath string
+		wantStates    []string
+		wantWarnings  []string
+		wantShadowing string
+	}{
+		{
+			"global in effect",
+			[]pairingStatus{{scope: "local"}, global},
+			global.path,
+			[]string{"shadowed", "effective"},
+			[]string{"", ""},
+			"",
+		},
+		{
+			"local shadows global",
+			[]pairingStatus{local, global},
+			local.path,
+			[]string{"effective", "shadowed"},
+			[]string{"", ""},
+			"local",
+		},
+		{
+			"dir shadows global",
+			[]pairingStatus{{scope: "local"}, dir, global},
+			dir.path,
+			[]string{"shadowed", "effective", "shadowed"},
+			[]string{"", "", ""},
+			"dir /src",
+		},
+		{
+			"own template shadows global",
+			[]pairingStatus{ownTemplate, global},
+			ownTemplate.path,
+			[]string{"effective", "shadowed"},
+			[]string{"", ""},
+			"local",
+		},
+		{
+			"local without a global pairing",
+			[]pairingStatus{local, notPairing},
+			local.path,
+			[]string{"effective", "shadowed"},
+			[]string{"", ""},
+			"",
+		},
+		{
+			"nothing in effect",
+			[]pairingStatus{{scope: "local"}, notPairing},
+			"",
+			[]string{"shadowed", "shadowed"},
+			[]string{"", ""},
+			"",
+		},
+		{
+			"missing template",
+			[]pairingStatus{missing, global},
+			missing.path,
+			[]string{"effective", "shadowed"},
+			[]string{missingWarning, ""},
+			"local",
+		},
+		{
+			"collaborator renamed",
+			[]pairingStatus{renamed, notPairing},
+			renamed.path,
+			[]string{"effective", "shadowed"},
+			[]string{staleWarning, ""},
+			"",
+		},
+		{
+			"collaborator removed",
+			[]pairingStatus{unknown, notPairing},
+			unknown.path,
+			[]string{"effective", "shadowed"},
+			[]string{staleWarning, ""},
+			"",
+		},
+		{
+			"dir outside a repo",
+			[]pairingStatus{inactive, global},
+			global.path,
+			[]string{"for repos in this directory", "effective"},
+			[]string{"", ""},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses := make([]pairingStatus, len(tt.statuses))
+			for i, status := range tt.statuses {
+				statuses[i] = status
+				statuses[i].coauthors = append([]coauthorStatus(nil), status.coauthors...)
+			}
+
+			shadowing := resolveStatuses(statuses, tt.effectivePath, roster)
+
+			var states, warnings []string
+			for _, status := range statuses {
+				states = append(states, status.state)
+				warnings = append(warnings, status.warning)
+			}
+			if !reflect.DeepEqual(states, tt.wantStates) {
+				t.Errorf("resolveStatuses() states = %v, want %v", states, tt.wantStates)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("resolveStatuses() warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+
+			gotShadowing := ""
+			if shadowing != nil {
+				gotShadowing = shadowing.scope
+			}
+			if gotShadowing != tt.wantShadowing {
+				t.Errorf("resolveStatuses() shadowing = %q, want %q", gotShadowing, tt.wantShadowing)
+			}
+		})
+	}
+}
+
+func Test_resolveStatuses_coauthors(t *testing.T) {
+	roster := []config.Collaborator{
+		{Alias: "al", Name: "Alice", Email: "alice@example.com"},
+		{Alias: "bo", Name: "Bob Smith", Email: "bob@example.com"},
+	}
+	statuses := []pairingStatus{{
+		scope:   "local",
+		path:    "/home/.gpair/repo.txt",
+		isGpair: true,
+		coauthors: []coauthorStatus{
+			{Collaborator: config.Collaborator{Name: "Alice", Email: "alice@example.com"}},
+			{Collaborator: config.Collaborator{Name: "Bob", Email: "bob@example.com"}},
+			{Collaborator: config.Collaborator{Name: "Carol", Email: "carol@example.com"}},
+		},
+	}}
+
+	resolveStatuses(statuses, "", roster)
+
+	want := []coauthorStatus{
+		{Collaborator: config.Collaborator{Alias: "al", Name: "Alice", Email: "alice@example.com"}},
+		{Collaborator: config.Collaborator{Alias: "bo", Name: "Bob", Email: "bob@example.com"}, note: "(now saved as Bob Smith)"},
+		{Collaborator: config.Collaborator{Name: "Carol", Email: "carol@example.com"}, note: "(not in your collaborators)"},
+	}
+	if !reflect.DeepEqual(statuses[0].coauthors, want) {
+		t.Errorf("resolveStatuses() coauthors = %v, want %v", statuses[0].coauthors, want)
+	}
+}
@@ -0,0 +1,173 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+	"github.com/adavidalbertson/gpair/internal/session"
+)
+
+// TemplateCmd is the flagset for the 'template' subcommand
+var TemplateCmd flag.FlagSet
+
+func init() {
+	TemplateCmd = *flag.NewFlagSet("template", flag.ExitOnError)
+	TemplateCmd.BoolVar(&internal.Help, "help", false, "Display usage information")
+	TemplateCmd.BoolVar(&internal.Help, "h", false, "\nDisplay usage information (shorthand)")
+	TemplateCmd.BoolVar(&internal.Verbose, "verbose", false, "Enable verbose output")
+	TemplateCmd.BoolVar(&internal.Verbose, "v", false, "\nEnable verbose output (shorthand)")
+	TemplateCmd.BoolVar(&globalMode, "global", false, "\nPreview the global template")
+	TemplateCmd.BoolVar(&globalMode, "g", false, "\nPreview the global template (shorthand)")
+	TemplateCmd.StringVar(&dirPath, "dir", "", "\nPreview the template for every repo in this directory")
+	TemplateCmd.BoolVar(&dcoMode, "dco", false, "\nPreview the template for the given aliases in the Co-developed-by style of the Linux kernel")
+	TemplateCmd.StringVar(&trailerKey, "trailer", "", "Preview the template crediting the alias right after this flag with this trailer, e.g. 'Reviewed-by'")
+	oldUsage := TemplateCmd.Usage
+	TemplateCmd.Usage = func() {
+		fmt.Println()
+		fmt.Println("The 'template' subcommand works with the commit template gpair writes when you pair.")
+		fmt.Println("Run 'gpair template preview [ALIAS ...]' to print the template for the current pairing, or for the given")
+		fmt.Println("aliases, without changing any git config.")
+		fmt.Println("To customize the template, point git's gpair.templateFile at a Go text/template file. It can use")
+		fmt.Println(".Coauthors, .Trailers (the lines crediting them), .Style, .Repo, .Branch, .Start, .Self, .Base (your own")
+		fmt.Println("commit template) and .CommentChar,")
+		fmt.Println("and the functions {{comment \"text\"}}, which renders a comment git removes, and {{join .Coauthors \"sep\"}}.")
+		fmt.Println()
+		oldUsage()
+		TemplateCmd.PrintDefaults()
+		fmt.Println()
+	}
+}
+
+// Template is the function executed by the 'template' subcommand
+// It renders the commit template without touching git config
+func Template() {
+	args, trailers, err := parseAliasArgs(&TemplateCmd, os.Args[2:])
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(0)
+	}
+
+	if internal.Help || len(args) == 0 {
+		TemplateCmd.Usage()
+		os.Exit(0)
+	}
+
+	if !git.IsInstalled() {
+		fmt.Println("git needs to be installed for gpair to work.")
+		os.Exit(0)
+	}
+
+	switch args[0] {
+	case "preview":
+		err = previewTemplate(args[1:], trailers)
+
+	default:
+		fmt.Printf("Unknown template action '%s'\n", args[0])
+		TemplateCmd.Usage()
+		os.Exit(0)
+	}
+
+	if err != nil {
+		if etf, ok := err.(*git.ErrTemplateFormat); ok {
+			fmt.Println(etf.Error())
+			os.Exit(0)
+		}
+
+		panic(err)
+	}
+}
+
+// previewTemplate prints the template for the collaborators with the given aliases, credited with the given trailers
+// as pairing with them would, or for the current pairing. It only reads the session, so it creates no state.
+func previewTemplate(aliases []string, trailers map[string]string) error {
+	err := useDirFlag(globalMode)
+	if err != nil {
+		return err
+	}
+
+	var coauthors []config.Collaborator
+	start := time.Now()
+	style := git.DefaultStyle
+	if len(aliases) > 0 {
+		configurator, err := config.NewConfigurator()
+		if err != nil {
+			return err
+		}
+
+		style, err = getTrailerStyle()
+		if err != nil {
+			return err
+		}
+
+		coauthors, err = configurator.GetCollaborators(aliases...)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(0)
+		}
+
+		coauthors, err = setTrailers(coauthors, trailers)
+		if err != nil {
+			return err
+		}
+	} else {
+		key, err := lookupSessionKey(globalMode)
+		if err != nil {
+			fmt.Println("gpair must be run inside a git repository unless in global mode")
+			os.Exit(0)
+		}
+
+		current, err := session.Peek(key)
+		if err != nil {
+			return err
+		}
+
+		if current.IsEmpty() {
+			fmt.Println("Not pairing, so the template would have no co-authors. Run 'gpair template preview ALIAS' to preview it with some.")
+		} else {
+			coauthors = current.GetCoauthors()
+			start = current.Start
+			style = current.Style
+		}
+	}
+
+	data, err := git.NewTemplateData(globalMode || dirScope != "", style, start, coauthors...)
+	if err != nil {
+		return err
+	}
+
+	backend, err := git.GetBackend()
+	if err != nil {
+		return err
+	}
+
+	// The hook backend appends the trailers to the commit message, so it has no template of its own to compose with
+	if backend == git.TemplateBackend {
+		data.Base, err = git.GetBaseTemplate(globalMode)
+		if err != nil {
+			return err
+		}
+	}
+
+	formatPath, err := git.GetTemplateFile()
+	if err != nil {
+		return err
+	}
+
+	if formatPath != "" {
+		internal.PrintVerbose("Rendering the template set in gpair.templateFile: %s", formatPath)
+	}
+
+	template, err := git.RenderTemplate(data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(template)
+
+	return nil
+}
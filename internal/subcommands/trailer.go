@@ -0,0 +1,91 @@
+package subcommands
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal/config"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// trailerKey is the value of the --trailer flag, which credits the alias after it with another trailer than Co-authored-by
+var trailerKey string
+
+// parseAliasArgs parses flags that may appear before, between or after aliases, like parseInterspersed,
+// and returns the aliases along with the trailer given with --trailer right before any of them
+func parseAliasArgs(flags *flag.FlagSet, args []string) ([]string, map[string]string, error) {
+	var aliases []string
+	trailers := make(map[string]string)
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			if trailerKey != "" {
+				return nil, nil, fmt.Errorf("--trailer %s must come before the alias it applies to", trailerKey)
+			}
+
+			return aliases, trailers, nil
+		}
+
+		if trailerKey != "" {
+			trailers[args[0]] = trailerKey
+			trailerKey = ""
+		}
+
+		aliases = append(aliases, args[0])
+		args = args[1:]
+	}
+}
+
+// setTrailers sets the trailer that credits each collaborator: the one given for its alias with --trailer,
+// or else the default set in git's gpair.trailer. It exits if a trailer is not a valid trailer key.
+func setTrailers(collaborators []config.Collaborator, trailers map[string]string) ([]config.Collaborator, error) {
+	defaultTrailer, err := git.GetDefaultTrailer()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, collaborator := range collaborators {
+		trailer, ok := trailers[collaborator.Alias]
+		if !ok {
+			trailer = defaultTrailer
+		}
+
+		if trailer != "" && !config.IsValidTrailer(trailer) {
+			fmt.Printf("'%s' is not a valid trailer. A trailer is made of letters, digits and dashes, like 'Reviewed-by'.\n", trailer)
+			exitWithError()
+		}
+
+		if strings.EqualFold(trailer, config.DefaultTrailer) {
+			trailer = ""
+		}
+		collaborators[i].Trailer = trailer
+	}
+
+	return collaborators, nil
+}
+
+// getTrailerStyle returns DCOStyle if the --dco flag is set, or else the style set in git's gpair.trailerStyle.
+// It exits if that is not a trailer style.
+func getTrailerStyle() (string, error) {
+	if dcoMode {
+		return git.DCOStyle, nil
+	}
+
+	style, err := git.GetTrailerStyle()
+	if err != nil {
+		if _, ok := err.(*git.ErrTrailerStyle); ok {
+			fmt.Println(err.Error())
+			exitWithError()
+		}
+
+		return "", err
+	}
+
+	return style, nil
+}
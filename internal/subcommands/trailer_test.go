@@ -0,0 +1,44 @@
+package subcommands
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseAliasArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         string
+		wantAliases  []string
+		wantTrailers map[string]string
+		wantErr      bool
+	}{
+		{"no trailers", "al bo", []string{"al", "bo"}, map[string]string{}, false},
+		{"trailer first", "--trailer Reviewed-by bo", []string{"bo"}, map[string]string{"bo": "Reviewed-by"}, false},
+		{"trailer between", "al --trailer Reviewed-by bo cy", []string{"al", "bo", "cy"}, map[string]string{"bo": "Reviewed-by"}, false},
+		{"several trailers", "--trailer Helped-by al -g --trailer Reviewed-by bo", []string{"al", "bo"}, map[string]string{"al": "Helped-by", "bo": "Reviewed-by"}, false},
+		{"trailer last", "al --trailer Reviewed-by", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trailerKey = ""
+			flags := flag.NewFlagSet("gpair", flag.ContinueOnError)
+			flags.Bool("g", false, "")
+			flags.StringVar(&trailerKey, "trailer", "", "")
+
+			gotAliases, gotTrailers, err := parseAliasArgs(flags, strings.Split(tt.args, " "))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAliasArgs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotAliases, tt.wantAliases) {
+				t.Errorf("parseAliasArgs() aliases = %v, want %v", gotAliases, tt.wantAliases)
+			}
+			if !reflect.DeepEqual(gotTrailers, tt.wantTrailers) {
+				t.Errorf("parseAliasArgs() trailers = %v, want %v", gotTrailers, tt.wantTrailers)
+			}
+		})
+	}
+	trailerKey = ""
+}
@@ -0,0 +1,92 @@
+package subcommands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/adavidalbertson/gpair/internal"
+	"github.com/adavidalbertson/gpair/internal/git"
+)
+
+// DetectWorktreeScope makes gpair keep the per-repo pairing in the config of the current worktree
+// if the repo lets each worktree have its own config. It is run on every invocation, before any config is read.
+func DetectWorktreeScope() {
+	if !git.IsInstalled() {
+		return
+	}
+
+	if _, err := git.GetRepoRoot(); err != nil {
+		return
+	}
+
+	enabled, err := git.IsWorktreeConfigEnabled()
+	if err != nil {
+		internal.PrintVerbose("Failed to check for extensions.worktreeConfig: %v", err)
+		return
+	}
+
+	if enabled {
+		git.UseWorktreeConfig()
+	}
+}
+
+// offerWorktreeScope gives the current worktree a pairing of its own, by enabling extensions.worktreeConfig.
+// Unless force is true, this is only offered if the repo has more than one worktree, and the user is asked first.
+func offerWorktreeScope(force bool) error {
+	if git.IsWorktreeScope() {
+		return nil
+	}
+
+	if !force {
+		worktrees, err := git.GetWorktrees()
+		if err != nil || len(worktrees) < 2 {
+			return err
+		}
+
+		tty, err := openTerminal()
+		if err != nil {
+			internal.PrintVerbose("This repo has %d worktrees which share a pairing. Run gpair with --worktree to give this one its own.", len(worktrees))
+			return nil
+		}
+		defer tty.Close()
+
+		consent, err := promptWorktreeScope(bufio.NewReader(tty), tty, len(worktrees))
+		if err != nil || !consent {
+			return err
+		}
+	}
+
+	err := git.EnableWorktreeConfig()
+	if err != nil {
+		fmt.Println(err.Error())
+		fmt.Println("Pairing in the shared config instead, so every worktree of this repo has the same pairing.")
+		return nil
+	}
+	git.UseWorktreeConfig()
+
+	err = git.MovePairingToWorktreeConfig()
+	if err != nil {
+		return err
+	}
+
+	internal.PrintVerbose("Enabled extensions.worktreeConfig, each worktree of this repo now has its own pairing")
+
+	return nil
+}
+
+// promptWorktreeScope asks whether to give each worktree its own pairing, and returns true if the user agrees
+func promptWorktreeScope(in *bufio.Reader, out io.Writer, worktrees int) (bool, error) {
+	fmt.Fprintf(out, "This repo has %d worktrees, which share a pairing unless git's extensions.worktreeConfig is enabled.\n", worktrees)
+	fmt.Fprint(out, "Enable it so each worktree has its own pairing? [y/N] ")
+
+	answer, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,33 @@
+package subcommands
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func Test_promptWorktreeScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"yes", "y\n", true},
+		{"yes in full", "Yes\n", true},
+		{"no", "n\n", false},
+		{"default", "\n", false},
+		{"no input", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := promptWorktreeScope(bufio.NewReader(strings.NewReader(tt.input)), ioutil.Discard, 2)
+			if err != nil {
+				t.Fatalf("promptWorktreeScope() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("promptWorktreeScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
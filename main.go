@@ -13,6 +13,9 @@ func main() {
 		os.Exit(0)
 	}
 
+	subcommands.DetectWorktreeScope()
+	subcommands.ExpireSessions()
+
 	switch os.Args[1] {
 	case subcommands.AddCmd.Name():
 		subcommands.Add()
@@ -26,6 +29,54 @@ func main() {
 	case subcommands.ListCmd.Name():
 		subcommands.List()
 
+	case subcommands.HookCmd.Name():
+		subcommands.Hook()
+
+	case subcommands.StatusCmd.Name():
+		subcommands.Status()
+
+	case subcommands.JoinCmd.Name():
+		subcommands.Join()
+
+	case subcommands.LeaveCmd.Name():
+		subcommands.Leave()
+
+	case subcommands.HistoryCmd.Name():
+		subcommands.History()
+
+	case subcommands.PauseCmd.Name():
+		subcommands.Pause()
+
+	case subcommands.ResumeCmd.Name():
+		subcommands.Resume()
+
+	case subcommands.LastCmd.Name():
+		subcommands.Last()
+
+	case subcommands.SessionsCmd.Name():
+		subcommands.Sessions()
+
+	case subcommands.GcCmd.Name():
+		subcommands.Gc()
+
+	case subcommands.TemplateCmd.Name():
+		subcommands.Template()
+
+	case subcommands.LintCmd.Name():
+		subcommands.Lint()
+
+	case subcommands.AmendCmd.Name():
+		subcommands.Amend()
+
+	case subcommands.CreditCmd.Name():
+		subcommands.Credit()
+
+	case subcommands.SquashMessageCmd.Name():
+		subcommands.SquashMessage()
+
+	case subcommands.StatsCmd.Name():
+		subcommands.Stats()
+
 	default:
 		subcommands.Pair()
 	}